@@ -7,6 +7,8 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"strings"
+	"sync"
 
 	yaml "gopkg.in/yaml.v2"
 )
@@ -14,8 +16,8 @@ import (
 var (
 	// BrokenMode signal if the application is broken
 	BrokenMode bool
-	// AvailableCameras list index of detected cameras
-	AvailableCameras []int
+	// AvailableRenderers list names of registered face renderers
+	AvailableRenderers []string
 
 	// Rootdir executable code to reach assets
 	Rootdir string
@@ -25,6 +27,109 @@ var (
 
 const brokenversion = "2.0alpha1"
 
+const (
+	// ScreenshotFilename is the latest captured frame, in Datadir
+	ScreenshotFilename = "screencapture.png"
+	// DetectedFilename is the latest frame with detected faces rendered, in Datadir
+	DetectedFilename = "screendetected.png"
+)
+
+// CameraFilename returns the name of a screenshot file for camera, inserting its number (offsetted by 1 like for
+// clients) before the extension. filename is returned unchanged for negative cameras.
+func CameraFilename(filename string, camera int) string {
+	if camera < 0 {
+		return filename
+	}
+	ext := filepath.Ext(filename)
+	return fmt.Sprintf("%s-%d%s", strings.TrimSuffix(filename, ext), camera+1, ext)
+}
+
+// CameraInfo is the name and supported modes of a camera
+type CameraInfo struct {
+	// Camera is offsetted by 1 like for clients
+	Camera int          `json:"camera"`
+	Name   string       `json:"name"`
+	Modes  []CameraMode `json:"modes"`
+}
+
+// CameraMode is a resolution supported by a camera, with its frame rates
+type CameraMode struct {
+	Width  int       `json:"width"`
+	Height int       `json:"height"`
+	FPS    []float64 `json:"fps"`
+}
+
+// CameraStatus tells if detection can run on cameras
+type CameraStatus string
+
+const (
+	// CAMERAOK is set when active cameras are opened or detection doesn't need any
+	CAMERAOK CameraStatus = "ok"
+	// NOCAMERA is set when the frame source is a camera but none is detected
+	NOCAMERA CameraStatus = "nocamera"
+	// CAMERAFAILED is set when an active camera can't be opened or was lost
+	CAMERAFAILED CameraStatus = "camerafailed"
+)
+
+// CameraState is the status of cameras, with the failing camera and the reason when not ok
+type CameraState struct {
+	Status CameraStatus `json:"status"`
+	// Camera is offsetted by 1 like for clients, 0 if no camera in particular is failing
+	Camera int    `json:"camera,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+var (
+	availableCameras []int
+	cameraInfos      []CameraInfo
+	camerasMutex     = &sync.Mutex{}
+)
+
+// AvailableCameras list index of detected cameras, offsetted by 1 like for clients
+func AvailableCameras() []int {
+	camerasMutex.Lock()
+	defer camerasMutex.Unlock()
+	return availableCameras
+}
+
+// CameraInfos describe detected cameras, in the same order as AvailableCameras
+func CameraInfos() []CameraInfo {
+	camerasMutex.Lock()
+	defer camerasMutex.Unlock()
+	return cameraInfos
+}
+
+// SetAvailableCameras replaces detected cameras and their description. Slices are never modified afterwards
+func SetAvailableCameras(cameras []int, infos []CameraInfo) {
+	camerasMutex.Lock()
+	defer camerasMutex.Unlock()
+	availableCameras = cameras
+	cameraInfos = infos
+}
+
+var (
+	cameraState      = CameraState{Status: CAMERAOK}
+	cameraStateMutex = &sync.Mutex{}
+)
+
+// CurrentCameraState returns current status of cameras
+func CurrentCameraState() CameraState {
+	cameraStateMutex.Lock()
+	defer cameraStateMutex.Unlock()
+	return cameraState
+}
+
+// SetCameraState changes current status of cameras. Return true if it changed
+func SetCameraState(state CameraState) bool {
+	cameraStateMutex.Lock()
+	defer cameraStateMutex.Unlock()
+	if state == cameraState {
+		return false
+	}
+	cameraState = state
+	return true
+}
+
 type versionYaml struct {
 	Version string `yaml:"version"`
 }
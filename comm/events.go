@@ -0,0 +1,132 @@
+package comm
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/ubuntu/face-detection-demo/messages"
+)
+
+// socket subscribers receiving events broadcast to websocket clients
+var subscribers = struct {
+	sync.Mutex
+	maxID int
+	chans map[int]chan *messages.Event
+}{chans: make(map[int]chan *messages.Event)}
+
+// publishEvent sends msg to every socket subscriber. Subscribers not keeping up are disconnected.
+func publishEvent(msg *messages.WSMessage) {
+	subscribers.Lock()
+	defer subscribers.Unlock()
+	if len(subscribers.chans) == 0 {
+		return
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		fmt.Println("Couldn't encode event:", err)
+		return
+	}
+	event := &messages.Event{Type: msg.Type, Json: data}
+	for id, ch := range subscribers.chans {
+		select {
+		case ch <- event:
+		default:
+			fmt.Printf("Socket subscriber %d is too slow, disconnecting it\n", id)
+			delete(subscribers.chans, id)
+			close(ch)
+		}
+	}
+}
+
+func subscribe() (int, <-chan *messages.Event) {
+	subscribers.Lock()
+	defer subscribers.Unlock()
+	subscribers.maxID++
+	ch := make(chan *messages.Event, channelBufSize)
+	subscribers.chans[subscribers.maxID] = ch
+	return subscribers.maxID, ch
+}
+
+func unsubscribe(id int) {
+	subscribers.Lock()
+	defer subscribers.Unlock()
+	if ch, ok := subscribers.chans[id]; ok {
+		delete(subscribers.chans, id)
+		close(ch)
+	}
+}
+
+// streamEvents sends matching events to conn until the peer disconnects or is too slow
+func streamEvents(conn net.Conn, sub *messages.Subscription) {
+	types := make(map[string]bool)
+	for _, t := range sub.Types {
+		types[t] = true
+	}
+
+	id, events := subscribe()
+	defer unsubscribe(id)
+
+	if err := writeFrame(conn, &messages.Response{Success: true}); err != nil {
+		fmt.Println("Couldn't send response:", err)
+		return
+	}
+
+	// subscribers don't send anything else: a read only returns once they disconnect
+	disconnected := make(chan interface{})
+	go func() {
+		conn.Read(make([]byte, 1))
+		close(disconnected)
+	}()
+
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			if len(types) > 0 && !types[e.Type] {
+				continue
+			}
+			if err := writeFrame(conn, e); err != nil {
+				return
+			}
+		case <-disconnected:
+			return
+		}
+	}
+}
+
+// WatchEvents subscribes to service events of given types (all if empty) and calls handler on each of them
+// until the connection is closed
+func WatchEvents(types []string, handler func(*messages.Event)) error {
+	conn, err := net.Dial("unix", socketpath)
+	if err != nil {
+		fmt.Println("Couldn't connect to socket. Is your service running?")
+		return err
+	}
+	defer conn.Close()
+
+	if err = writeFrame(conn, &messages.Request{Subscribe: &messages.Subscription{Types: types}}); err != nil {
+		fmt.Println("Couldn't write to socket:", err)
+		return err
+	}
+	resp := new(messages.Response)
+	if err = readFrame(conn, resp); err != nil {
+		fmt.Println("Couldn't read response from socket:", err)
+		return err
+	}
+	if !resp.Success {
+		return fmt.Errorf("subscription refused: %s", resp.Error)
+	}
+
+	for {
+		e := new(messages.Event)
+		if err = readFrame(conn, e); err != nil {
+			return err
+		}
+		handler(e)
+	}
+}
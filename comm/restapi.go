@@ -0,0 +1,741 @@
+package comm
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/ubuntu/face-detection-demo/appstate"
+	"github.com/ubuntu/face-detection-demo/datastore"
+	"github.com/ubuntu/face-detection-demo/messages"
+)
+
+// REST API, version 1. Changes are sent as actions to the main loop like websocket and socket ones, and so
+// are applied asynchronously: they are answered with 202 Accepted once validated.
+// Camera numbers are offsetted by 1 like for websocket clients.
+
+const apiPrefix = "/v1/"
+
+type apiSettings struct {
+	FaceDetection bool `json:"facedetection"`
+	// RenderingMode is the renderer name, kept for existing clients
+	RenderingMode      string                    `json:"renderingmode"`
+	Renderer           datastore.Renderer        `json:"renderer"`
+	AvailableRenderers []string                  `json:"availablerenderers"`
+	Camera             int                       `json:"camera"`
+	Cameras            []int                     `json:"cameras"`
+	Source             datastore.FrameSourceKind `json:"source"`
+	SourcePath         string                    `json:"sourcepath"`
+	Sampling           datastore.Sampling        `json:"sampling"`
+	Retention          datastore.Retention       `json:"retention"`
+	Privacy            datastore.Privacy         `json:"privacy"`
+	Detector           datastore.DetectorKind    `json:"detector"`
+	Cascades           datastore.Cascades        `json:"cascades"`
+}
+
+// apiSettingsPatch only contains fields to change
+type apiSettingsPatch struct {
+	FaceDetection *bool               `json:"facedetection"`
+	RenderingMode *string             `json:"renderingmode"`
+	Renderer      *datastore.Renderer `json:"renderer"`
+	Camera        *int                `json:"camera"`
+	Cameras       []int               `json:"cameras"`
+	Source        *string             `json:"source"`
+	SourcePath    *string             `json:"sourcepath"`
+	Sampling      *struct {
+		Policy          *string  `json:"policy"`
+		IntervalMs      *int     `json:"intervalms"`
+		FrameStep       *int     `json:"framestep"`
+		MaxFPS          *float64 `json:"maxfps"`
+		MotionThreshold *float64 `json:"motionthreshold"`
+		MotionGate      *bool    `json:"motiongate"`
+	} `json:"sampling"`
+	Retention *struct {
+		RawDays    *int  `json:"rawdays"`
+		Downsample *bool `json:"downsample"`
+	} `json:"retention"`
+	Privacy *struct {
+		Enabled *bool   `json:"enabled"`
+		Method  *string `json:"method"`
+	} `json:"privacy"`
+	Detector *string `json:"detector"`
+	Cascades *struct {
+		Models       []string `json:"models"`
+		ScaleFactor  *float64 `json:"scalefactor"`
+		MinNeighbors *int     `json:"minneighbors"`
+		MinSize      *int     `json:"minsize"`
+		MaxSize      *int     `json:"maxsize"`
+		NMSThreshold *float64 `json:"nmsthreshold"`
+	} `json:"cascades"`
+}
+
+type apiCameras struct {
+	Available []int `json:"available"`
+	// Active is the main camera, first of all cameras detection runs on
+	Active  int                   `json:"active"`
+	Cameras []int                 `json:"cameras"`
+	Infos   []appstate.CameraInfo `json:"infos"`
+	// State tells if detection can run on cameras, and why not
+	State *appstate.CameraState `json:"state,omitempty"`
+}
+
+// apiCamera is an available camera with its capture settings
+type apiCamera struct {
+	appstate.CameraInfo
+	Params datastore.CameraParams `json:"params"`
+}
+
+// apiCameraParamsPatch only contains capture settings to change
+type apiCameraParamsPatch struct {
+	Width    *int     `json:"width"`
+	Height   *int     `json:"height"`
+	FPS      *float64 `json:"fps"`
+	Exposure *float64 `json:"exposure"`
+}
+
+type apiDetection struct {
+	Enabled bool `json:"enabled"`
+}
+
+type apiError struct {
+	Error string `json:"error"`
+}
+
+var snapshotFiles = map[string]string{
+	"capture":  appstate.ScreenshotFilename,
+	"detected": appstate.DetectedFilename,
+}
+
+func registerAPIHandlers() {
+	http.HandleFunc(apiPrefix, apiNotFound)
+	http.HandleFunc(apiPrefix+"stats", apiStats)
+	http.HandleFunc(apiPrefix+"visits", apiVisits)
+	http.HandleFunc(apiPrefix+"settings", apiSettingsHandler)
+	http.HandleFunc(apiPrefix+"cameras", apiCamerasHandler)
+	http.HandleFunc(apiPrefix+"cameras/", apiCameraHandler)
+	http.HandleFunc(apiPrefix+"detection", apiDetectionHandler)
+	http.HandleFunc(apiPrefix+"snapshot", apiSnapshot)
+	http.HandleFunc(apiPrefix+"zones", apiZones)
+	http.HandleFunc(apiPrefix+"zones/", apiZone)
+}
+
+func apiNotFound(w http.ResponseWriter, r *http.Request) {
+	writeJSONError(w, http.StatusNotFound, fmt.Errorf("unknown api endpoint: %s", r.URL.Path))
+}
+
+// GET: raw stats, or aggregated ones if bucket is set. Accepts from and to (RFC3339) parameters. Both can be
+// restricted to one camera with camera.
+func apiStats(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, "GET") {
+		return
+	}
+
+	q := r.URL.Query()
+	from, to, err := parseTimeRange(q.Get("from"), q.Get("to"), defaultQueryRange)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	camera, err := cameraParam(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if q.Get("bucket") != "" {
+		bucket, err := datastore.ParseBucket(q.Get("bucket"))
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, err)
+			return
+		}
+		var aggregates []datastore.AggregatedStat
+		if camera != 0 {
+			// camera is offsetted by 1 for the client
+			aggregates, err = datastore.DB.CameraAggregate(camera-1, bucket, from, to)
+		} else {
+			aggregates, err = datastore.DB.Aggregate(bucket, from, to)
+		}
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, fmt.Errorf("couldn't aggregate stats: %s", err))
+			return
+		}
+		if aggregates == nil {
+			aggregates = []datastore.AggregatedStat{}
+		}
+		writeJSON(w, http.StatusOK, aggregates)
+		return
+	}
+
+	var stats []datastore.Stat
+	if camera != 0 {
+		// camera is offsetted by 1 for the client
+		stats, err = datastore.DB.CameraStatsBetween(camera-1, from, to)
+	} else {
+		stats, err = datastore.DB.StatsBetween(from, to)
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, fmt.Errorf("couldn't load stats: %s", err))
+		return
+	}
+	if stats == nil {
+		stats = []datastore.Stat{}
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+// GET: number of visitors and dwell time distribution of visits started between from and to (RFC3339) parameters.
+// With list=true, visits themselves are returned.
+func apiVisits(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, "GET") {
+		return
+	}
+
+	q := r.URL.Query()
+	from, to, err := parseTimeRange(q.Get("from"), q.Get("to"), defaultQueryRange)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if q.Get("list") == "true" {
+		visits, err := datastore.DB.VisitsBetween(from, to)
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, fmt.Errorf("couldn't fetch visits: %s", err))
+			return
+		}
+		if visits == nil {
+			visits = []datastore.Visit{}
+		}
+		writeJSON(w, http.StatusOK, visits)
+		return
+	}
+
+	stats, err := datastore.DB.VisitStats(from, to)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, fmt.Errorf("couldn't compute visit stats: %s", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+// GET: current settings. PATCH: change any subset of them.
+func apiSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, "GET", "PATCH") {
+		return
+	}
+
+	if r.Method == "GET" {
+		source, sourcepath := datastore.FrameSource()
+		renderer := datastore.FaceRenderer()
+		writeJSON(w, http.StatusOK, apiSettings{
+			FaceDetection:      datastore.FaceDetection(),
+			RenderingMode:      renderer.Name,
+			Renderer:           renderer,
+			AvailableRenderers: appstate.AvailableRenderers,
+			Camera:             datastore.Camera() + 1,
+			Cameras:            messages.ClientCameras(datastore.Cameras()),
+			Source:             source,
+			SourcePath:         sourcepath,
+			Sampling:           datastore.FrameSampling(),
+			Retention:          datastore.DataRetention(),
+			Privacy:            datastore.PrivacyMode(),
+			Detector:           datastore.DetectorBackend(),
+			Cascades:           datastore.HaarCascades(),
+		})
+		return
+	}
+
+	var patch apiSettingsPatch
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		writeJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid settings: %s", err))
+		return
+	}
+	action, err := actionFromPatch(&patch)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	acceptAction(w, action)
+}
+
+// GET: available and active cameras. POST: change active camera, or all cameras detection runs on with cameras.
+func apiCamerasHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, "GET", "POST") {
+		return
+	}
+
+	if r.Method == "GET" {
+		state := appstate.CurrentCameraState()
+		writeJSON(w, http.StatusOK, apiCameras{
+			Available: appstate.AvailableCameras(),
+			Active:    datastore.Camera() + 1,
+			Cameras:   messages.ClientCameras(datastore.Cameras()),
+			Infos:     appstate.CameraInfos(),
+			State:     &state,
+		})
+		return
+	}
+
+	var cameras apiCameras
+	if err := json.NewDecoder(r.Body).Decode(&cameras); err != nil {
+		writeJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid camera request: %s", err))
+		return
+	}
+	if cameras.Cameras != nil {
+		action, err := activeCamerasAction(cameras.Cameras)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, err)
+			return
+		}
+		acceptAction(w, &messages.Action{ActiveCameras: action})
+		return
+	}
+	if err := validateCamera(cameras.Active); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	acceptAction(w, &messages.Action{Camera: int32(cameras.Active)})
+}
+
+// GET: camera numbered after the path, with its capture settings. PATCH: change its capture settings, 0 going back
+// to the camera default.
+func apiCameraHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, "GET", "PATCH") {
+		return
+	}
+	param := strings.TrimPrefix(r.URL.Path, apiPrefix+"cameras/")
+	camera, err := strconv.Atoi(param)
+	if err == nil {
+		err = validateCamera(camera)
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, fmt.Errorf("unknown camera: %s", param))
+		return
+	}
+
+	// camera is offsetted by 1 for the client
+	params := datastore.CameraParameters(camera - 1)
+	if r.Method == "GET" {
+		info := apiCamera{Params: params}
+		for _, i := range appstate.CameraInfos() {
+			if i.Camera == camera {
+				info.CameraInfo = i
+			}
+		}
+		writeJSON(w, http.StatusOK, info)
+		return
+	}
+
+	var patch apiCameraParamsPatch
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		writeJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid capture settings: %s", err))
+		return
+	}
+	// validate the resulting capture settings as a whole. 0 means unchanged in actions, negative resets to 0
+	action := &messages.CameraParams{Camera: int32(camera)}
+	if patch.Width != nil {
+		action.Width = zeroAsNegative(*patch.Width)
+		params.Width = *patch.Width
+	}
+	if patch.Height != nil {
+		action.Height = zeroAsNegative(*patch.Height)
+		params.Height = *patch.Height
+	}
+	if patch.FPS != nil {
+		action.Fps = *patch.FPS
+		if *patch.FPS == 0 {
+			action.Fps = -1
+		}
+		params.FPS = *patch.FPS
+	}
+	if patch.Exposure != nil {
+		action.Exposure = *patch.Exposure
+		action.AutoExposure = *patch.Exposure == 0
+		params.Exposure = *patch.Exposure
+	}
+	if err := params.Validate(); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	acceptAction(w, &messages.Action{CameraParams: action})
+}
+
+// GET: detection state. POST: enable or disable it.
+func apiDetectionHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, "GET", "POST") {
+		return
+	}
+
+	if r.Method == "GET" {
+		writeJSON(w, http.StatusOK, apiDetection{Enabled: datastore.FaceDetection()})
+		return
+	}
+
+	var detection apiDetection
+	if err := json.NewDecoder(r.Body).Decode(&detection); err != nil {
+		writeJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid detection request: %s", err))
+		return
+	}
+	acceptAction(w, &messages.Action{FaceDetection: faceDetectionState(detection.Enabled)})
+}
+
+// GET: latest captured image, or latest one with rendered faces with type=detected. camera selects the camera,
+// the main one by default
+func apiSnapshot(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, "GET") {
+		return
+	}
+
+	snapshottype := r.URL.Query().Get("type")
+	if snapshottype == "" {
+		snapshottype = "capture"
+	}
+	filename, ok := snapshotFiles[snapshottype]
+	if !ok {
+		writeJSONError(w, http.StatusBadRequest, fmt.Errorf("unknown snapshot type: %s", snapshottype))
+		return
+	}
+	camera, err := cameraParam(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	// main camera and other frame sources screenshots keep the historical names
+	if camera != 0 && camera-1 != datastore.Camera() {
+		filename = appstate.CameraFilename(filename, camera-1)
+	}
+
+	filepath := path.Join(datadir, filename)
+	if _, err := os.Stat(filepath); os.IsNotExist(err) {
+		writeJSONError(w, http.StatusNotFound, fmt.Errorf("no %s snapshot available yet", snapshottype))
+		return
+	}
+	http.ServeFile(w, r, filepath)
+}
+
+// GET: all zones. POST: create a zone or replace the one with the same name.
+func apiZones(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, "GET", "POST") {
+		return
+	}
+
+	if r.Method == "GET" {
+		zones := datastore.Zones()
+		if zones == nil {
+			zones = []datastore.Zone{}
+		}
+		writeJSON(w, http.StatusOK, zones)
+		return
+	}
+
+	var zone datastore.Zone
+	if err := json.NewDecoder(r.Body).Decode(&zone); err != nil {
+		writeJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid zone: %s", err))
+		return
+	}
+	setZone(w, zone)
+}
+
+// GET: zone named after the path. PUT: create or replace it with the points of the request. DELETE: remove it.
+func apiZone(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, "GET", "PUT", "DELETE") {
+		return
+	}
+	name := strings.TrimPrefix(r.URL.Path, apiPrefix+"zones/")
+
+	if r.Method == "PUT" {
+		var zone datastore.Zone
+		if err := json.NewDecoder(r.Body).Decode(&zone); err != nil {
+			writeJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid zone: %s", err))
+			return
+		}
+		zone.Name = name
+		setZone(w, zone)
+		return
+	}
+
+	var zone *datastore.Zone
+	for _, z := range datastore.Zones() {
+		if z.Name == name {
+			zone = &z
+			break
+		}
+	}
+	if zone == nil {
+		writeJSONError(w, http.StatusNotFound, fmt.Errorf("unknown zone: %s", name))
+		return
+	}
+
+	if r.Method == "GET" {
+		writeJSON(w, http.StatusOK, zone)
+		return
+	}
+	acceptAction(w, &messages.Action{DeleteZone: name})
+}
+
+func setZone(w http.ResponseWriter, zone datastore.Zone) {
+	if err := zone.Validate(); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	acceptAction(w, &messages.Action{SetZone: messages.ZoneToMessage(zone)})
+}
+
+// actionFromPatch validates requested changes and converts them to an action
+func actionFromPatch(patch *apiSettingsPatch) (*messages.Action, error) {
+	action := &messages.Action{}
+
+	if patch.FaceDetection != nil {
+		action.FaceDetection = faceDetectionState(*patch.FaceDetection)
+	}
+	if patch.RenderingMode != nil {
+		patch.Renderer = &datastore.Renderer{Name: *patch.RenderingMode}
+	}
+	if patch.Renderer != nil {
+		if err := validateRenderer(patch.Renderer.Name); err != nil {
+			return nil, err
+		}
+		action.Renderer = &messages.Renderer{Name: patch.Renderer.Name, Params: patch.Renderer.Params}
+	}
+	if patch.Camera != nil {
+		if err := validateCamera(*patch.Camera); err != nil {
+			return nil, err
+		}
+		action.Camera = int32(*patch.Camera)
+	}
+	if patch.Cameras != nil {
+		cameras, err := activeCamerasAction(patch.Cameras)
+		if err != nil {
+			return nil, err
+		}
+		action.ActiveCameras = cameras
+	}
+	if patch.Source != nil {
+		source, ok := messages.FrameSources[*patch.Source]
+		if !ok {
+			return nil, fmt.Errorf("unknown frame source: %s", *patch.Source)
+		}
+		action.Source = source
+	}
+	if patch.SourcePath != nil {
+		action.SourcePath = *patch.SourcePath
+	}
+
+	if s := patch.Sampling; s != nil {
+		// validate the resulting sampling settings as a whole
+		sampling := datastore.FrameSampling()
+		if s.Policy != nil {
+			policy, ok := messages.SamplingPolicies[*s.Policy]
+			if !ok {
+				return nil, fmt.Errorf("unknown sampling policy: %s", *s.Policy)
+			}
+			action.Sampling = policy
+			sampling.Policy = datastore.SamplingPolicy(*s.Policy)
+		}
+		if s.IntervalMs != nil {
+			action.CaptureIntervalMs = int32(*s.IntervalMs)
+			sampling.IntervalMs = *s.IntervalMs
+		}
+		if s.FrameStep != nil {
+			action.FrameStep = int32(*s.FrameStep)
+			sampling.FrameStep = *s.FrameStep
+		}
+		if s.MaxFPS != nil {
+			action.MaxFPS = float32(*s.MaxFPS)
+			sampling.MaxFPS = *s.MaxFPS
+		}
+		if s.MotionThreshold != nil {
+			action.MotionThreshold = float32(*s.MotionThreshold)
+			sampling.MotionThreshold = *s.MotionThreshold
+		}
+		if s.MotionGate != nil {
+			action.MotionGate = messages.Action_MOTIONGATE_DISABLE
+			if *s.MotionGate {
+				action.MotionGate = messages.Action_MOTIONGATE_ENABLE
+			}
+			sampling.MotionGate = *s.MotionGate
+		}
+		if err := sampling.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	if r := patch.Retention; r != nil {
+		if r.RawDays != nil {
+			if *r.RawDays < 0 {
+				return nil, fmt.Errorf("retention days can't be negative, use 0 to keep stats forever")
+			}
+			// 0 means unchanged in actions, negative keeps stats forever
+			action.RetentionDays = int32(*r.RawDays)
+			if *r.RawDays == 0 {
+				action.RetentionDays = -1
+			}
+		}
+		if r.Downsample != nil {
+			action.Downsampling = messages.Action_DOWNSAMPLING_DISABLE
+			if *r.Downsample {
+				action.Downsampling = messages.Action_DOWNSAMPLING_ENABLE
+			}
+		}
+	}
+
+	if p := patch.Privacy; p != nil {
+		if p.Enabled != nil {
+			action.Privacy = messages.Action_PRIVACY_DISABLE
+			if *p.Enabled {
+				action.Privacy = messages.Action_PRIVACY_ENABLE
+			}
+		}
+		if p.Method != nil {
+			method, ok := messages.PrivacyMethods[*p.Method]
+			if !ok {
+				return nil, fmt.Errorf("unknown privacy method: %s", *p.Method)
+			}
+			action.PrivacyMethod = method
+		}
+	}
+
+	if patch.Detector != nil {
+		detector, ok := messages.Detectors[*patch.Detector]
+		if !ok {
+			return nil, fmt.Errorf("unknown detector: %s", *patch.Detector)
+		}
+		action.Detector = detector
+	}
+
+	if c := patch.Cascades; c != nil {
+		// validate the resulting cascades settings as a whole. 0 means unchanged in actions, negative resets to 0
+		cascades := datastore.HaarCascades()
+		action.Cascades = &messages.HaarCascades{}
+		if c.Models != nil {
+			action.Cascades.Models = c.Models
+			cascades.Models = c.Models
+		}
+		if c.ScaleFactor != nil {
+			action.Cascades.ScaleFactor = float32(*c.ScaleFactor)
+			cascades.ScaleFactor = *c.ScaleFactor
+		}
+		if c.MinNeighbors != nil {
+			action.Cascades.MinNeighbors = zeroAsNegative(*c.MinNeighbors)
+			cascades.MinNeighbors = *c.MinNeighbors
+		}
+		if c.MinSize != nil {
+			action.Cascades.MinSize = zeroAsNegative(*c.MinSize)
+			cascades.MinSize = *c.MinSize
+		}
+		if c.MaxSize != nil {
+			action.Cascades.MaxSize = zeroAsNegative(*c.MaxSize)
+			cascades.MaxSize = *c.MaxSize
+		}
+		if c.NMSThreshold != nil {
+			action.Cascades.NmsThreshold = float32(*c.NMSThreshold)
+			cascades.NMSThreshold = *c.NMSThreshold
+		}
+		if err := cascades.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return action, nil
+}
+
+// zeroAsNegative converts a value which can be 0 to an action one, where 0 means unchanged
+func zeroAsNegative(v int) int32 {
+	if v == 0 {
+		return -1
+	}
+	return int32(v)
+}
+
+// activeCamerasAction validates cameras detection should run on and converts them to an action
+func activeCamerasAction(cameras []int) (*messages.ActiveCameras, error) {
+	action := &messages.ActiveCameras{}
+	internal := make([]int, 0, len(cameras))
+	for _, c := range cameras {
+		if err := validateCamera(c); err != nil {
+			return nil, err
+		}
+		action.Cameras = append(action.Cameras, int32(c))
+		internal = append(internal, c-1)
+	}
+	if err := datastore.ValidateCameras(internal); err != nil {
+		return nil, err
+	}
+	return action, nil
+}
+
+// cameraParam returns the camera number of the camera query parameter, offsetted by 1. 0 if not set
+func cameraParam(r *http.Request) (int, error) {
+	param := r.URL.Query().Get("camera")
+	if param == "" {
+		return 0, nil
+	}
+	camera, err := strconv.Atoi(param)
+	if err != nil || camera < 0 {
+		return 0, fmt.Errorf("invalid camera: %s", param)
+	}
+	return camera, nil
+}
+
+func validateCamera(camera int) error {
+	for _, c := range appstate.AvailableCameras() {
+		if c == camera {
+			return nil
+		}
+	}
+	return fmt.Errorf("camera %d is not available", camera)
+}
+
+func validateRenderer(name string) error {
+	for _, r := range appstate.AvailableRenderers {
+		if r == name {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown renderer: %s", name)
+}
+
+func faceDetectionState(enabled bool) messages.Action_FaceDetectionState {
+	if enabled {
+		return messages.Action_FACEDETECTION_ENABLE
+	}
+	return messages.Action_FACEDETECTION_DISABLE
+}
+
+// acceptAction sends the action to the main loop and tells the client it will be processed
+func acceptAction(w http.ResponseWriter, action *messages.Action) {
+	WSserv.NewAction(action)
+	writeJSON(w, http.StatusAccepted, struct {
+		Status string `json:"status"`
+	}{"accepted"})
+}
+
+// allowMethods returns false and answers with an error if request method isn't in methods
+func allowMethods(w http.ResponseWriter, r *http.Request, methods ...string) bool {
+	for _, m := range methods {
+		if r.Method == m {
+			return true
+		}
+	}
+	for _, m := range methods {
+		w.Header().Add("Allow", m)
+	}
+	writeJSONError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		fmt.Println("Couldn't encode api response:", err)
+	}
+}
+
+func writeJSONError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, apiError{err.Error()})
+}
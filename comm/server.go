@@ -1,15 +1,22 @@
 package comm
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"path"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/ubuntu/face-detection-demo/datastore"
 	"github.com/ubuntu/face-detection-demo/messages"
 )
 
+// default time range for stats queries without explicit bounds
+const defaultQueryRange = 24 * time.Hour
+
 var (
 	datadir string
 	rootdir string
@@ -24,6 +31,10 @@ func StartServer(rootd string, datad string, actions chan<- *messages.Action) {
 	go func() {
 		go WSserv.Listen()
 		http.HandleFunc("/data/", serveFileData)
+		http.HandleFunc("/data/export", serveExport)
+		http.HandleFunc("/aggregates", serveAggregates)
+		registerAPIHandlers()
+		http.Handle("/metrics", promhttp.Handler())
 		http.Handle("/", http.FileServer(http.Dir(path.Join(rootdir, "www"))))
 		if err := http.ListenAndServe(":8080", nil); err != nil {
 			log.Fatal("Couldn't start webserver:", err)
@@ -47,3 +58,95 @@ func serveFileData(w http.ResponseWriter, r *http.Request) {
 	}
 	http.ServeFile(w, r, filepath)
 }
+
+// serveAggregates returns stats aggregated per bucket in json. Optional parameters are bucket (minute, hour or day),
+// from and to (RFC3339) and camera (offsetted by 1). Defaults to hourly aggregates of all cameras of the last 24 hours.
+func serveAggregates(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	from, to, err := parseTimeRange(q.Get("from"), q.Get("to"), defaultQueryRange)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	bucketname := q.Get("bucket")
+	if bucketname == "" {
+		bucketname = string(datastore.HOURBUCKET)
+	}
+	bucket, err := datastore.ParseBucket(bucketname)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	camera, err := cameraParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var aggregates []datastore.AggregatedStat
+	if camera != 0 {
+		aggregates, err = datastore.DB.CameraAggregate(camera-1, bucket, from, to)
+	} else {
+		aggregates, err = datastore.DB.Aggregate(bucket, from, to)
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Couldn't aggregate stats: %s", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(aggregates)
+}
+
+// serveExport streams stats as a downloadable file. Optional parameters are format (csv, json or ndjson),
+// from and to (RFC3339). Defaults to the whole history in csv.
+func serveExport(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	from, to, err := parseTimeRange(q.Get("from"), q.Get("to"), 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	formatname := q.Get("format")
+	if formatname == "" {
+		formatname = string(datastore.CSVEXPORT)
+	}
+	format, err := datastore.ParseExportFormat(formatname)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", exportContentTypes[format])
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"stats.%s\"", format))
+	// headers are already sent once streaming started: we can only log errors
+	if err = datastore.DB.Export(w, format, from, to); err != nil {
+		fmt.Println("Couldn't export stats:", err)
+	}
+}
+
+var exportContentTypes = map[datastore.ExportFormat]string{
+	datastore.CSVEXPORT:    "text/csv",
+	datastore.JSONEXPORT:   "application/json",
+	datastore.NDJSONEXPORT: "application/x-ndjson",
+}
+
+// parseTimeRange parses RFC3339 from and to. Empty to is now, empty from is defaultRange before to, or the
+// beginning of times if defaultRange is 0.
+func parseTimeRange(fromstr, tostr string, defaultRange time.Duration) (from time.Time, to time.Time, err error) {
+	to = time.Now()
+	if tostr != "" {
+		if to, err = time.Parse(time.RFC3339, tostr); err != nil {
+			return from, to, fmt.Errorf("invalid to time: %s", err)
+		}
+	}
+	if defaultRange != 0 {
+		from = to.Add(-defaultRange)
+	}
+	if fromstr != "" {
+		if from, err = time.Parse(time.RFC3339, fromstr); err != nil {
+			return from, to, fmt.Errorf("invalid from time: %s", err)
+		}
+	}
+	return from, to, nil
+}
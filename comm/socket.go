@@ -1,6 +1,7 @@
 package comm
 
 import (
+	"encoding/binary"
 	"fmt"
 	"io"
 	"log"
@@ -23,8 +24,17 @@ func init() {
 	socketpath = path.Join(appstate.Datadir, socketfilename)
 }
 
+// SocketRequest is a request received on the socket, waiting for the main loop to send back its response
+type SocketRequest struct {
+	Request  *messages.Request
+	Response chan<- *messages.Response
+}
+
+// maximum size of a framed message, to not allocate garbage lengths
+const maxFrameSize = 1 << 20
+
 // StartSocketListener executes a socket listener in its own goroutine
-func StartSocketListener(actions chan<- *messages.Action, shutdown <-chan interface{}, forcecreation bool, wg *sync.WaitGroup) {
+func StartSocketListener(requests chan<- SocketRequest, shutdown <-chan interface{}, forcecreation bool, wg *sync.WaitGroup) {
 
 	wg.Add(1)
 	go func() {
@@ -56,7 +66,7 @@ func StartSocketListener(actions chan<- *messages.Action, shutdown <-chan interf
 						return
 					}
 				}
-				go fetchSocketMessage(conn, actions)
+				go handleSocketRequest(conn, requests)
 			}
 		}()
 
@@ -68,52 +78,75 @@ func StartSocketListener(actions chan<- *messages.Action, shutdown <-chan interf
 
 }
 
-// SendToSocket will send an action message to socket message
-func SendToSocket(msg *messages.Action) (err error) {
+// SendToSocket sends a request to the service and waits for its response
+func SendToSocket(req *messages.Request) (*messages.Response, error) {
 	conn, err := net.Dial("unix", socketpath)
 	if err != nil {
 		fmt.Println("Couldn't connect to socket. Is your service running?")
-		return
+		return nil, err
 	}
 	defer conn.Close()
 
-	data, err := proto.Marshal(msg)
-	if err != nil {
-		fmt.Println("Can't convert received data to protobuf message:", err)
-	}
-
-	if _, err = conn.Write(data); err != nil {
+	if err = writeFrame(conn, req); err != nil {
 		fmt.Println("Couldn't write to socket:", err)
-		return
+		return nil, err
 	}
 
-	return nil
+	resp := new(messages.Response)
+	if err = readFrame(conn, resp); err != nil {
+		fmt.Println("Couldn't read response from socket:", err)
+		return nil, err
+	}
+	return resp, nil
 }
 
-func fetchSocketMessage(conn net.Conn, actions chan<- *messages.Action) {
+func handleSocketRequest(conn net.Conn, requests chan<- SocketRequest) {
 	defer conn.Close()
 
-	msg := new(messages.Action)
+	req := new(messages.Request)
+	if err := readFrame(conn, req); err != nil {
+		fmt.Println("Receiving not well formatted data:", err)
+		writeFrame(conn, &messages.Response{Error: fmt.Sprintf("invalid request: %s", err)})
+		return
+	}
+
+	if req.Subscribe != nil {
+		streamEvents(conn, req.Subscribe)
+		return
+	}
 
-	result := make([]byte, 0, 4096)
-	length := 0
-	buf := make([]byte, 256)
-	for {
-		n, err := conn.Read(buf)
-		if err != nil && err != io.EOF {
-			fmt.Println("Error receiving data: ", err)
-			return
-		}
-		result = append(result, buf[:n]...)
-		length += n
-		if err == io.EOF {
-			break
-		}
+	response := make(chan *messages.Response, 1)
+	requests <- SocketRequest{req, response}
+	if err := writeFrame(conn, <-response); err != nil {
+		fmt.Println("Couldn't send response:", err)
 	}
+}
 
-	if err := proto.Unmarshal(result[:length], msg); err != nil {
-		fmt.Println("Receiving not well formatted data: ", err, "as:", result[:length])
+// writeFrame sends msg prefixed by its length
+func writeFrame(w io.Writer, msg proto.Message) error {
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return err
 	}
+	header := make([]byte, 4)
+	binary.BigEndian.PutUint32(header, uint32(len(data)))
+	_, err = w.Write(append(header, data...))
+	return err
+}
 
-	actions <- msg
+// readFrame reads a length prefixed message into msg
+func readFrame(r io.Reader, msg proto.Message) error {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return err
+	}
+	length := binary.BigEndian.Uint32(header)
+	if length > maxFrameSize {
+		return fmt.Errorf("message of %d bytes exceeds maximum size", length)
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return err
+	}
+	return proto.Unmarshal(data, msg)
 }
@@ -5,8 +5,11 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"time"
 
+	"github.com/ubuntu/face-detection-demo/datastore"
 	"github.com/ubuntu/face-detection-demo/messages"
+	"github.com/ubuntu/face-detection-demo/metrics"
 
 	"golang.org/x/net/websocket"
 )
@@ -47,6 +50,7 @@ func (c *Client) Send(msg *messages.WSMessage) {
 	select {
 	case c.ch <- msg:
 	default:
+		metrics.WSDroppedSends.Inc()
 		c.server.Del(c)
 		err := fmt.Errorf("client %d is disconnected", c.id)
 		c.server.Err(err)
@@ -101,7 +105,97 @@ func (c *Client) listenRead() {
 			} else if err != nil {
 				c.server.Err(err)
 			}
+			// queries are answered directly to this client
+			if action.AggregateQuery != nil {
+				c.sendAggregates(action.AggregateQuery)
+				action.AggregateQuery = nil
+			}
+			if action.HistoryQuery != nil {
+				c.sendHistory(action.HistoryQuery)
+				action.HistoryQuery = nil
+			}
+			if action.VisitQuery != nil {
+				c.sendVisitStats(action.VisitQuery)
+				action.VisitQuery = nil
+			}
 			c.server.NewAction(&action)
 		}
 	}
 }
+
+func (c *Client) sendAggregates(query *messages.AggregateQuery) {
+	bucket, err := datastore.ParseBucket(query.Bucket)
+	if err != nil {
+		c.server.Err(err)
+		return
+	}
+	to := time.Now()
+	if query.To != 0 {
+		to = time.Unix(query.To, 0)
+	}
+	from := to.Add(-defaultQueryRange)
+	if query.From != 0 {
+		from = time.Unix(query.From, 0)
+	}
+
+	var aggregates []datastore.AggregatedStat
+	if query.Camera != 0 {
+		// camera is offsetted by 1 for the client
+		aggregates, err = datastore.DB.CameraAggregate(int(query.Camera)-1, bucket, from, to)
+	} else {
+		aggregates, err = datastore.DB.Aggregate(bucket, from, to)
+	}
+	if err != nil {
+		c.server.Err(fmt.Errorf("couldn't aggregate stats for client %d: %s", c.id, err))
+		return
+	}
+	c.Send(&messages.WSMessage{
+		Type:       "aggregates",
+		Aggregates: aggregates})
+}
+
+func (c *Client) sendVisitStats(query *messages.VisitQuery) {
+	to := time.Now()
+	if query.To != 0 {
+		to = time.Unix(query.To, 0)
+	}
+	from := to.Add(-defaultQueryRange)
+	if query.From != 0 {
+		from = time.Unix(query.From, 0)
+	}
+
+	stats, err := datastore.DB.VisitStats(from, to)
+	if err != nil {
+		c.server.Err(fmt.Errorf("couldn't compute visit stats for client %d: %s", c.id, err))
+		return
+	}
+	c.Send(&messages.WSMessage{
+		Type:       "visitstats",
+		VisitStats: &stats})
+}
+
+// sendHistory sends a page of stats older than the query cursor, or newer than its after stat id
+func (c *Client) sendHistory(query *messages.HistoryQuery) {
+	msg := &messages.WSMessage{Type: "history"}
+	var err error
+	if query.After != 0 {
+		// more means newer stats are available: fetch them from the last received stat
+		msg.History, msg.HasMoreHistory, err = datastore.DB.StatsAfter(query.After, int(query.Limit))
+	} else {
+		msg.History, msg.HasMoreHistory, err = datastore.DB.StatsBefore(query.Before, int(query.Limit))
+		msg.HistoryCursor = historyCursor(msg.History, query.Before)
+	}
+	if err != nil {
+		c.server.Err(fmt.Errorf("couldn't fetch history for client %d: %s", c.id, err))
+		return
+	}
+	c.Send(msg)
+}
+
+// historyCursor is the id of the oldest stat sent, to fetch the previous page. Keep current one if none were sent
+func historyCursor(stats []datastore.Stat, current int64) int64 {
+	if len(stats) == 0 {
+		return current
+	}
+	return stats[0].ID
+}
@@ -4,10 +4,12 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"sync/atomic"
 
 	"github.com/ubuntu/face-detection-demo/appstate"
 	"github.com/ubuntu/face-detection-demo/datastore"
 	"github.com/ubuntu/face-detection-demo/messages"
+	"github.com/ubuntu/face-detection-demo/metrics"
 
 	"golang.org/x/net/websocket"
 )
@@ -27,6 +29,8 @@ type WSServer struct {
 	doneCh     chan interface{}
 	errCh      chan error
 	actions    chan<- *messages.Action
+	// numClients is updated by the listener goroutine, to be read from any other
+	numClients int32
 }
 
 // NewWSServer create a new ws server
@@ -47,6 +51,7 @@ func NewWSServer(patternURL string, actions chan<- *messages.Action) *WSServer {
 		doneCh,
 		errCh,
 		actions,
+		0,
 	}
 }
 
@@ -60,6 +65,11 @@ func (s *WSServer) SendAllClients(msg *messages.WSMessage) {
 	s.sendAllCh <- msg
 }
 
+// NumClients returns the number of connected clients
+func (s *WSServer) NumClients() int {
+	return int(atomic.LoadInt32(&s.numClients))
+}
+
 // Done signal we are shutting down the ws server
 func (s *WSServer) Done() {
 	close(s.doneCh)
@@ -108,22 +118,52 @@ func (s *WSServer) Listen() {
 		case c := <-s.addCh:
 			log.Println("New client connected")
 			s.clients[c.id] = c
+			metrics.WSClients.Set(float64(len(s.clients)))
+			atomic.StoreInt32(&s.numClients, int32(len(s.clients)))
 			log.Println("Now", len(s.clients), "clients connected.")
-			// send all stats messages
+			source, sourcepath := datastore.FrameSource()
+			sampling := datastore.FrameSampling()
+			retention := datastore.DataRetention()
+			renderer := datastore.FaceRenderer()
+			privacy := datastore.PrivacyMode()
+			cascades := datastore.HaarCascades()
+			cameraState := appstate.CurrentCameraState()
+			// only send most recent stats, older ones are fetched on demand from the cursor
+			stats, more, err := datastore.DB.StatsBefore(0, datastore.HistoryPageSize)
+			if err != nil {
+				fmt.Println("Couldn't fetch recent stats:", err)
+			}
 			c.Send(&messages.WSMessage{
-				Type:          "init",
-				AllStats:      datastore.DB.Stats,
-				FaceDetection: datastore.FaceDetection(),
-				RenderingMode: datastore.RenderingMode(),
+				Type:           "init",
+				AllStats:       stats,
+				HistoryCursor:  historyCursor(stats, 0),
+				HasMoreHistory: more,
+				FaceDetection:  datastore.FaceDetection(),
+				Renderer:       &renderer,
 				// camera is offsetted by 1 for the client
-				Camera:           datastore.Camera() + 1,
-				AvailableCameras: appstate.AvailableCameras,
-				Broken:           appstate.BrokenMode})
+				Camera:             datastore.Camera() + 1,
+				ActiveCameras:      messages.ClientCameras(datastore.Cameras()),
+				AvailableCameras:   appstate.AvailableCameras(),
+				CameraInfos:        appstate.CameraInfos(),
+				CameraParams:       messages.ClientCameraParams(datastore.AllCameraParameters()),
+				CameraState:        &cameraState,
+				AvailableRenderers: appstate.AvailableRenderers,
+				Source:             source,
+				SourcePath:         sourcepath,
+				Sampling:           &sampling,
+				Retention:          &retention,
+				Privacy:            &privacy,
+				Detector:           datastore.DetectorBackend(),
+				Cascades:           &cascades,
+				Zones:              datastore.Zones(),
+				Broken:             appstate.BrokenMode})
 
 		// client disconnected
 		case c := <-s.delCh:
 			log.Println("Disconnected client")
 			delete(s.clients, c.id)
+			metrics.WSClients.Set(float64(len(s.clients)))
+			atomic.StoreInt32(&s.numClients, int32(len(s.clients)))
 
 		// broadcast message to all clients
 		case msg := <-s.sendAllCh:
@@ -131,6 +171,7 @@ func (s *WSServer) Listen() {
 			for _, c := range s.clients {
 				c.Send(msg)
 			}
+			publishEvent(msg)
 
 		// error reported
 		case err := <-s.errCh:
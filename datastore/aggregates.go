@@ -0,0 +1,116 @@
+package datastore
+
+import (
+	"fmt"
+	"time"
+)
+
+// Bucket is the time span stats are aggregated over
+type Bucket string
+
+const (
+	// MINUTEBUCKET aggregates stats per minute
+	MINUTEBUCKET Bucket = "minute"
+	// HOURBUCKET aggregates stats per hour
+	HOURBUCKET Bucket = "hour"
+	// DAYBUCKET aggregates stats per day (UTC)
+	DAYBUCKET Bucket = "day"
+)
+
+// sqlite strftime format truncating a timestamp to the start of its bucket
+var bucketFormats = map[Bucket]string{
+	MINUTEBUCKET: "%Y-%m-%d %H:%M:00",
+	HOURBUCKET:   "%Y-%m-%d %H:00:00",
+	DAYBUCKET:    "%Y-%m-%d 00:00:00",
+}
+
+const bucketTimeLayout = "2006-01-02 15:04:05"
+
+// AggregatedStat summarizes number of persons over a time bucket starting at Start
+type AggregatedStat struct {
+	Start time.Time
+	Min   int
+	Max   int
+	Avg   float64
+	Count int
+}
+
+// ParseBucket returns the bucket corresponding to its name
+func ParseBucket(name string) (Bucket, error) {
+	b := Bucket(name)
+	if _, ok := bucketFormats[b]; !ok {
+		return "", fmt.Errorf("unknown aggregation bucket: %s", name)
+	}
+	return b, nil
+}
+
+// Aggregate returns min, max, average and count of persons per bucket for stats of all cameras in the [from, to] time
+// range. Buckets without any stat are not returned. Hourly and daily buckets include hourly aggregates of raw stats
+// dropped by the retention policy.
+func (db *Database) Aggregate(bucket Bucket, from, to time.Time) ([]AggregatedStat, error) {
+	return db.aggregate(bucket, from, to, "")
+}
+
+// CameraAggregate is like Aggregate, restricted to stats taken on camera (-1 for other frame sources)
+func (db *Database) CameraAggregate(camera int, bucket Bucket, from, to time.Time) ([]AggregatedStat, error) {
+	return db.aggregate(bucket, from, to, "AND Camera = ?", camera)
+}
+
+// aggregate runs the aggregation query, stats and hourly aggregates being restricted by filter with its args
+func (db *Database) aggregate(bucket Bucket, from, to time.Time, filter string,
+	fargs ...interface{}) ([]AggregatedStat, error) {
+	format, ok := bucketFormats[bucket]
+	if !ok {
+		return nil, fmt.Errorf("unknown aggregation bucket: %s", bucket)
+	}
+
+	query := `
+	SELECT strftime(?, TimeStamp) AS Bucket, MIN(NumPersons), MAX(NumPersons), AVG(NumPersons), COUNT(*)
+	FROM stats
+	WHERE julianday(TimeStamp) BETWEEN julianday(?) AND julianday(?) ` + filter + `
+	GROUP BY Bucket
+	ORDER BY Bucket ASC
+	`
+	args := append([]interface{}{format, from, to}, fargs...)
+
+	if bucket != MINUTEBUCKET {
+		// weight hourly averages by their number of stats
+		query = `
+		SELECT strftime(?, Start) AS Bucket, MIN(MinPersons), MAX(MaxPersons), SUM(AvgPersons*Count)*1.0/SUM(Count), SUM(Count)
+		FROM (
+			SELECT TimeStamp AS Start, NumPersons AS MinPersons, NumPersons AS MaxPersons, NumPersons AS AvgPersons, 1 AS Count
+			FROM stats
+			WHERE julianday(TimeStamp) BETWEEN julianday(?) AND julianday(?) ` + filter + `
+			UNION ALL
+			SELECT Hour, MinPersons, MaxPersons, AvgPersons, Count
+			FROM hourly_stats
+			WHERE julianday(Hour) BETWEEN julianday(?) AND julianday(?) ` + filter + `
+		)
+		GROUP BY Bucket
+		ORDER BY Bucket ASC
+		`
+		args = append(args, from, to)
+		args = append(args, fargs...)
+	}
+
+	rows, err := db.dbconn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []AggregatedStat
+	for rows.Next() {
+		var start string
+		a := AggregatedStat{}
+		if err = rows.Scan(&start, &a.Min, &a.Max, &a.Avg, &a.Count); err != nil {
+			return nil, err
+		}
+		// strftime returns UTC times
+		if a.Start, err = time.Parse(bucketTimeLayout, start); err != nil {
+			return nil, err
+		}
+		result = append(result, a)
+	}
+	return result, rows.Err()
+}
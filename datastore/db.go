@@ -4,24 +4,54 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
-	"os"
 	"path"
 	"sync"
 	"time"
+
+	"github.com/ubuntu/face-detection-demo/metrics"
 )
 
 // Stat is a datapoint in time of collected face detected stats
 type Stat struct {
+	ID         int64
 	TimeStamp  time.Time
 	NumPersons int
+	// Camera the stat was taken on, -1 for other frame sources
+	Camera int
+	// Zones is the number of persons per zone, for zones defined when the stat was taken
+	Zones map[string]int `json:",omitempty"`
+}
+
+// Detection is a face bounding box detected in the frame of a Stat
+type Detection struct {
+	StatID      int64
+	TimeStamp   time.Time
+	X           int
+	Y           int
+	Width       int
+	Height      int
+	FrameWidth  int
+	FrameHeight int
+	Camera      int
+	Renderer    string
+	// TrackID identifies the same face across frames. 0 if the face wasn't tracked
+	TrackID int64
+	// TrackStart is when the track was first seen
+	TrackStart time.Time
 }
 
 // Database is the global DB handler
 type Database struct {
-	dbconn *sql.DB
-	// Stats has the full collection of added Stat from the DB or live
-	Stats   []Stat
-	newstat chan Stat
+	dbconn   *sql.DB
+	newstat  chan newStat
+	newvisit chan Visit
+}
+
+// newStat is a stat with its detections waiting to be inserted, saved being called once they are
+type newStat struct {
+	stat       Stat
+	detections []Detection
+	saved      func(Stat, []Detection)
 }
 
 var (
@@ -33,19 +63,13 @@ const storagefilename = "storage.db"
 
 // StartDB opens and run the DB in its own goroutine
 func StartDB(dir string, shutdown <-chan interface{}, wg *sync.WaitGroup) {
-	dbconn, err := sql.Open("sqlite3", path.Join(dir, storagefilename))
+	dbpath := path.Join(dir, storagefilename)
+	dbconn, err := openDB(dbpath)
 	if err != nil {
-		log.Fatal("Couldn't open DB", err)
+		log.Fatal("Couldn't open DB: ", err)
 	}
 
-	createTable(dbconn)
-	stats, err := fetchAllStats(dbconn)
-	if err != nil {
-		log.Fatal("Couldn't load DB data", err)
-		dbconn.Close()
-	}
-
-	DB = Database{dbconn: dbconn, Stats: stats, newstat: make(chan Stat)}
+	DB = Database{dbconn: dbconn, newstat: make(chan newStat), newvisit: make(chan Visit)}
 
 	wg.Add(1)
 	go func() {
@@ -53,11 +77,33 @@ func StartDB(dir string, shutdown <-chan interface{}, wg *sync.WaitGroup) {
 		defer DB.dbconn.Close()
 		defer fmt.Println("Close database")
 
+		DB.applyRetention()
+		retentionTicker := time.NewTicker(retentionInterval)
+		defer retentionTicker.Stop()
+
 		for {
 			select {
+			case <-retentionTicker.C:
+				DB.applyRetention()
+
 			case s := <-DB.newstat:
-				DB.Stats = append(DB.Stats, s)
-				DB.insertStat(s)
+				id := DB.insertStat(s.stat, s.detections)
+				if id == 0 {
+					metrics.DBInsertFailures.Inc()
+					break
+				}
+				if s.saved != nil {
+					s.stat.ID = id
+					for i := range s.detections {
+						s.detections[i].StatID = id
+					}
+					s.saved(s.stat, s.detections)
+				}
+
+			case v := <-DB.newvisit:
+				if !DB.insertVisit(v) {
+					metrics.DBInsertFailures.Inc()
+				}
 
 			case <-shutdown:
 				return
@@ -68,68 +114,151 @@ func StartDB(dir string, shutdown <-chan interface{}, wg *sync.WaitGroup) {
 
 }
 
-func createTable(db *sql.DB) {
-	// create table if doesn't exist
-	createquery := `
-	CREATE TABLE IF NOT EXISTS stats(
-		TimeStamp DATETIME,
-		NumPersons INTEGER
-	);
+// StatsBetween returns all stats in the [from, to] time range
+func (db *Database) StatsBetween(from, to time.Time) ([]Stat, error) {
+	query := `
+	SELECT rowid, TimeStamp, NumPersons, Camera FROM stats
+	WHERE julianday(TimeStamp) BETWEEN julianday(?) AND julianday(?)
+	ORDER BY TimeStamp ASC
 	`
-
-	if _, err := db.Exec(createquery); err != nil {
-		log.Fatal("Couldn't create table", err)
-	}
+	return db.queryStats(query, from, to)
 }
 
-func fetchAllStats(db *sql.DB) (result []Stat, err error) {
-	readallquery := `
-	SELECT TimeStamp, NumPersons FROM stats
+// CameraStatsBetween returns stats taken on camera (-1 for other frame sources) in the [from, to] time range
+func (db *Database) CameraStatsBetween(camera int, from, to time.Time) ([]Stat, error) {
+	query := `
+	SELECT rowid, TimeStamp, NumPersons, Camera FROM stats
+	WHERE julianday(TimeStamp) BETWEEN julianday(?) AND julianday(?) AND Camera = ?
 	ORDER BY TimeStamp ASC
 	`
+	return db.queryStats(query, from, to, camera)
+}
+
+// Add current stat with its face detections to the DB. saved, if not nil, is called from the DB goroutine once they
+// are inserted, with their ids set. It isn't called if they couldn't be saved.
+func (db *Database) Add(s Stat, detections []Detection, saved func(Stat, []Detection)) {
+	db.newstat <- newStat{s, detections, saved}
+}
+
+// Detections returns all face detections attached to a stat
+func (db *Database) Detections(statID int64) ([]Detection, error) {
+	query := `
+	SELECT d.StatID, s.TimeStamp, d.X, d.Y, d.Width, d.Height, d.FrameWidth, d.FrameHeight, d.Camera, d.Renderer,
+		d.TrackID, d.TrackStart
+	FROM detections d JOIN stats s ON s.rowid = d.StatID
+	WHERE d.StatID = ?
+	`
+	return db.queryDetections(query, statID)
+}
 
-	rows, err := db.Query(readallquery)
+// DetectionsBetween returns all face detections from stats in the [from, to] time range
+func (db *Database) DetectionsBetween(from, to time.Time) ([]Detection, error) {
+	query := `
+	SELECT d.StatID, s.TimeStamp, d.X, d.Y, d.Width, d.Height, d.FrameWidth, d.FrameHeight, d.Camera, d.Renderer,
+		d.TrackID, d.TrackStart
+	FROM detections d JOIN stats s ON s.rowid = d.StatID
+	WHERE julianday(s.TimeStamp) BETWEEN julianday(?) AND julianday(?)
+	ORDER BY s.TimeStamp ASC
+	`
+	return db.queryDetections(query, from, to)
+}
+
+// LastTrackID returns the highest track id saved, so that new tracks get unique ids
+func (db *Database) LastTrackID() (id int64, err error) {
+	err = db.dbconn.QueryRow("SELECT IFNULL(MAX(TrackID), 0) FROM detections").Scan(&id)
+	return id, err
+}
+
+func (db *Database) queryDetections(query string, args ...interface{}) (result []Detection, err error) {
+	rows, err := db.dbconn.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		s := Stat{}
-		if err = rows.Scan(&s.TimeStamp, &s.NumPersons); err != nil {
+		d := Detection{}
+		if err = rows.Scan(&d.StatID, &d.TimeStamp, &d.X, &d.Y, &d.Width, &d.Height,
+			&d.FrameWidth, &d.FrameHeight, &d.Camera, &d.Renderer, &d.TrackID, &d.TrackStart); err != nil {
 			return nil, err
 		}
-		result = append(result, s)
+		result = append(result, d)
 	}
-	return result, nil
-}
-
-// Add current stat to the DB and global stats
-func (db *Database) Add(s Stat) {
-	db.newstat <- s
+	return result, rows.Err()
 }
 
-func (db *Database) insertStat(s Stat) {
+// insert stat and its detections in a single transaction. Return the stat id (0 if it couldn't be saved)
+func (db *Database) insertStat(s Stat, detections []Detection) int64 {
 	addquery := `
 	INSERT INTO stats(
 		TimeStamp,
+		NumPersons,
+		Camera
+	) values(?, ?, ?)
+	`
+	adddetectionquery := `
+	INSERT INTO detections(
+		StatID,
+		X,
+		Y,
+		Width,
+		Height,
+		FrameWidth,
+		FrameHeight,
+		Camera,
+		Renderer,
+		TrackID,
+		TrackStart
+	) values(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	addzonequery := `
+	INSERT INTO zone_stats(
+		StatID,
+		Zone,
 		NumPersons
-	) values(?, ?)
+	) values(?, ?, ?)
 	`
 
-	stmt, err := db.dbconn.Prepare(addquery)
+	tx, err := db.dbconn.Begin()
 	if err != nil {
-		fmt.Println("Couldn't prepare insert query", err)
-		return
+		fmt.Println("Couldn't start transaction", err)
+		return 0
 	}
-	defer stmt.Close()
 
-	if _, err2 := stmt.Exec(s.TimeStamp, s.NumPersons); err2 != nil {
+	res, err := tx.Exec(addquery, s.TimeStamp, s.NumPersons, s.Camera)
+	if err != nil {
 		fmt.Println("Couldn't save", s, ":", err)
+		tx.Rollback()
+		return 0
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		fmt.Println("Couldn't get id of", s, ":", err)
+		tx.Rollback()
+		return 0
+	}
+
+	for _, d := range detections {
+		if _, err = tx.Exec(adddetectionquery, id, d.X, d.Y, d.Width, d.Height,
+			d.FrameWidth, d.FrameHeight, d.Camera, d.Renderer, d.TrackID, d.TrackStart); err != nil {
+			fmt.Println("Couldn't save detection", d, ":", err)
+			tx.Rollback()
+			return 0
+		}
 	}
-}
 
-// WipeDB removes database in dir unconditionally (existing or not)
-func WipeDB(dir string) {
-	os.Remove(path.Join(dir, storagefilename))
+	for zone, n := range s.Zones {
+		if _, err = tx.Exec(addzonequery, id, zone, n); err != nil {
+			fmt.Println("Couldn't save zone count", zone, ":", err)
+			tx.Rollback()
+			return 0
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		fmt.Println("Couldn't commit", s, ":", err)
+		return 0
+	}
+	return id
 }
@@ -0,0 +1,7 @@
+//go:build !noopencv
+// +build !noopencv
+
+package datastore
+
+// defaultDetector is used when no valid detector is set
+const defaultDetector = HAARDETECTOR
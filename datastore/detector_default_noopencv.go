@@ -0,0 +1,7 @@
+//go:build noopencv
+// +build noopencv
+
+package datastore
+
+// defaultDetector is used when no valid detector is set. Haar cascades need opencv
+const defaultDetector = PICODETECTOR
@@ -0,0 +1,161 @@
+package datastore
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// ExportFormat is the file format stats are exported to
+type ExportFormat string
+
+const (
+	// CSVEXPORT exports one stat per line with a header
+	CSVEXPORT ExportFormat = "csv"
+	// JSONEXPORT exports a json array of stats
+	JSONEXPORT ExportFormat = "json"
+	// NDJSONEXPORT exports one json stat per line
+	NDJSONEXPORT ExportFormat = "ndjson"
+)
+
+type exportedStat struct {
+	TimeStamp  string `json:"timestamp"`
+	NumPersons int    `json:"persons"`
+	// Camera is offsetted by 1 like for clients, 0 for other frame sources
+	Camera int `json:"camera"`
+}
+
+// ParseExportFormat returns the export format corresponding to its name
+func ParseExportFormat(name string) (ExportFormat, error) {
+	switch f := ExportFormat(name); f {
+	case CSVEXPORT, JSONEXPORT, NDJSONEXPORT:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown export format: %s", name)
+}
+
+// Export writes stats in the [from, to] time range to w. Rows are streamed from the database one at a time
+// so that exporting a large history doesn't need to load it all in memory.
+func (db *Database) Export(w io.Writer, format ExportFormat, from, to time.Time) error {
+	query := `
+	SELECT TimeStamp, NumPersons, Camera FROM stats
+	WHERE julianday(TimeStamp) BETWEEN julianday(?) AND julianday(?)
+	ORDER BY TimeStamp ASC
+	`
+
+	rows, err := db.dbconn.Query(query, from, to)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var e exporter
+	switch format {
+	case CSVEXPORT:
+		e = newCSVExporter(w)
+	case JSONEXPORT:
+		e = &jsonExporter{w: w, array: true}
+	case NDJSONEXPORT:
+		e = &jsonExporter{w: w}
+	default:
+		return fmt.Errorf("unknown export format: %s", format)
+	}
+
+	if err = e.start(); err != nil {
+		return err
+	}
+	for rows.Next() {
+		var s exportedStat
+		var t time.Time
+		if err = rows.Scan(&t, &s.NumPersons, &s.Camera); err != nil {
+			return err
+		}
+		s.TimeStamp = t.Format(time.RFC3339)
+		s.Camera++
+		if err = e.write(s); err != nil {
+			return err
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	return e.end()
+}
+
+type exporter interface {
+	start() error
+	write(exportedStat) error
+	end() error
+}
+
+type csvExporter struct {
+	w *csv.Writer
+}
+
+func newCSVExporter(w io.Writer) *csvExporter {
+	return &csvExporter{csv.NewWriter(w)}
+}
+
+func (e *csvExporter) start() error {
+	return e.w.Write([]string{"timestamp", "persons", "camera"})
+}
+
+func (e *csvExporter) write(s exportedStat) error {
+	return e.w.Write([]string{s.TimeStamp, strconv.Itoa(s.NumPersons), strconv.Itoa(s.Camera)})
+}
+
+func (e *csvExporter) end() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// jsonExporter writes either a json array or newline delimited json objects
+type jsonExporter struct {
+	w     io.Writer
+	array bool
+	first bool
+}
+
+func (e *jsonExporter) start() error {
+	e.first = true
+	if !e.array {
+		return nil
+	}
+	_, err := io.WriteString(e.w, "[")
+	return err
+}
+
+func (e *jsonExporter) write(s exportedStat) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	sep := "\n"
+	if e.array && !e.first {
+		sep = ",\n"
+	}
+	if !e.array {
+		// ndjson: separator goes at the end of each line
+		data = append(data, '\n')
+		sep = ""
+	}
+	e.first = false
+
+	if _, err = io.WriteString(e.w, sep); err != nil {
+		return err
+	}
+	_, err = e.w.Write(data)
+	return err
+}
+
+func (e *jsonExporter) end() error {
+	if !e.array {
+		return nil
+	}
+	_, err := io.WriteString(e.w, "\n]\n")
+	return err
+}
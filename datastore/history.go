@@ -0,0 +1,85 @@
+package datastore
+
+const (
+	// HistoryPageSize is the default number of stats in a history page
+	HistoryPageSize = 500
+	// MaxHistoryPageSize caps the number of stats a client can fetch at once
+	MaxHistoryPageSize = 5000
+)
+
+// StatsBefore returns up to limit most recent stats with an ID lower than before (or the most recent ones if
+// before is 0), oldest first. more is true if older stats are available.
+func (db *Database) StatsBefore(before int64, limit int) (result []Stat, more bool, err error) {
+	limit = pageSize(limit)
+	query := `
+	SELECT rowid, TimeStamp, NumPersons, Camera FROM stats
+	WHERE ? = 0 OR rowid < ?
+	ORDER BY rowid DESC
+	LIMIT ?
+	`
+
+	// fetch an additional stat to know if there are more
+	result, err = db.queryStats(query, before, before, limit+1)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(result) > limit {
+		result, more = result[:limit], true
+	}
+	// reverse to get oldest first
+	for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+		result[i], result[j] = result[j], result[i]
+	}
+	return result, more, nil
+}
+
+// StatsAfter returns up to limit stats with an ID greater than after, oldest first. more is true if newer stats are
+// available.
+func (db *Database) StatsAfter(after int64, limit int) (result []Stat, more bool, err error) {
+	limit = pageSize(limit)
+	query := `
+	SELECT rowid, TimeStamp, NumPersons, Camera FROM stats
+	WHERE rowid > ?
+	ORDER BY rowid ASC
+	LIMIT ?
+	`
+
+	result, err = db.queryStats(query, after, limit+1)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(result) > limit {
+		result, more = result[:limit], true
+	}
+	return result, more, nil
+}
+
+func (db *Database) queryStats(query string, args ...interface{}) (result []Stat, err error) {
+	rows, err := db.dbconn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		s := Stat{}
+		if err = rows.Scan(&s.ID, &s.TimeStamp, &s.NumPersons, &s.Camera); err != nil {
+			return nil, err
+		}
+		result = append(result, s)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, db.addZoneCounts(result)
+}
+
+func pageSize(limit int) int {
+	if limit <= 0 {
+		return HistoryPageSize
+	}
+	if limit > MaxHistoryPageSize {
+		return MaxHistoryPageSize
+	}
+	return limit
+}
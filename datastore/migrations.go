@@ -0,0 +1,243 @@
+package datastore
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+)
+
+// migrations upgrade the database schema, in order. Applying migrations[i] moves the schema from version i to i+1.
+// The current version is stored in sqlite user_version header. Never modify an existing migration: append a new one.
+var migrations = []string{
+	// 1: initial stats table (was created without any versioning, hence the IF NOT EXISTS)
+	`
+	CREATE TABLE IF NOT EXISTS stats(
+		TimeStamp DATETIME,
+		NumPersons INTEGER
+	);
+	`,
+	// 2: face detections, referencing the rowid of their stat
+	`
+	CREATE TABLE IF NOT EXISTS detections(
+		StatID INTEGER,
+		X INTEGER,
+		Y INTEGER,
+		Width INTEGER,
+		Height INTEGER,
+		FrameWidth INTEGER,
+		FrameHeight INTEGER,
+		Camera INTEGER,
+		RenderingMode INTEGER
+	);
+	CREATE INDEX IF NOT EXISTS detections_statid ON detections(StatID);
+	`,
+	// 3: hourly aggregates of raw stats dropped by retention. Hour is the UTC start of the hour
+	`
+	CREATE TABLE hourly_stats(
+		Hour DATETIME PRIMARY KEY,
+		MinPersons INTEGER,
+		MaxPersons INTEGER,
+		AvgPersons REAL,
+		Count INTEGER
+	);
+	`,
+	// 4: renderer name of detections, replacing the numeric rendering mode (0: normal, 1: fun)
+	`
+	ALTER TABLE detections ADD COLUMN Renderer TEXT;
+	UPDATE detections SET Renderer = CASE RenderingMode WHEN 1 THEN 'fun' ELSE 'normal' END;
+	`,
+	// 5: track of detections across frames. Previous detections weren't tracked (0) and start with their stat
+	`
+	ALTER TABLE detections ADD COLUMN TrackID INTEGER DEFAULT 0;
+	ALTER TABLE detections ADD COLUMN TrackStart DATETIME;
+	UPDATE detections SET TrackID = 0, TrackStart = (SELECT TimeStamp FROM stats WHERE stats.rowid = detections.StatID);
+	CREATE INDEX detections_trackid ON detections(TrackID);
+	`,
+	// 6: visits of tracked persons, from their first to their last detection
+	`
+	CREATE TABLE visits(
+		TrackID INTEGER PRIMARY KEY,
+		Camera INTEGER,
+		Enter DATETIME,
+		Exit DATETIME
+	);
+	CREATE INDEX visits_enter ON visits(Enter);
+	`,
+	// 7: number of persons per zone of stats
+	`
+	CREATE TABLE zone_stats(
+		StatID INTEGER,
+		Zone TEXT,
+		NumPersons INTEGER
+	);
+	CREATE INDEX zone_stats_statid ON zone_stats(StatID);
+	`,
+	// 8: camera of stats. Previous ones take the camera of their detections, or camera 0 without any
+	`
+	ALTER TABLE stats ADD COLUMN Camera INTEGER DEFAULT 0;
+	UPDATE stats SET Camera = IFNULL((SELECT Camera FROM detections WHERE detections.StatID = stats.rowid LIMIT 1), 0);
+	`,
+	// 9: camera of hourly aggregates, each camera having its own. Previous ones are attributed to camera 0
+	`
+	CREATE TABLE hourly_stats_cameras(
+		Hour DATETIME,
+		Camera INTEGER,
+		MinPersons INTEGER,
+		MaxPersons INTEGER,
+		AvgPersons REAL,
+		Count INTEGER,
+		PRIMARY KEY(Hour, Camera)
+	);
+	INSERT INTO hourly_stats_cameras SELECT Hour, 0, MinPersons, MaxPersons, AvgPersons, Count FROM hourly_stats;
+	DROP TABLE hourly_stats;
+	ALTER TABLE hourly_stats_cameras RENAME TO hourly_stats;
+	`,
+}
+
+// SchemaVersion is the database schema version this code knows about
+var SchemaVersion = len(migrations)
+
+// openDB opens the database at dbpath and migrates it to the current schema. A database of a newer schema, left by
+// a reverted upgrade, is first replaced by the backup taken when it was upgraded from the current schema
+func openDB(dbpath string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", dbpath)
+	if err != nil {
+		return nil, err
+	}
+	version, err := schemaVersion(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if version > SchemaVersion {
+		db.Close()
+		if err = restoreBackup(dbpath, version); err != nil {
+			return nil, err
+		}
+		if db, err = sql.Open("sqlite3", dbpath); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = migrate(db, dbpath); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// restoreBackup replaces the database at dbpath, of a newer schema version, by the backup of the current schema
+// version. The newer database is itself backed up first, so that upgrading again can reuse it
+func restoreBackup(dbpath string, version int) error {
+	backup := fmt.Sprintf("%s.v%d.bak", dbpath, SchemaVersion)
+	if _, err := os.Stat(backup); err != nil {
+		return fmt.Errorf("database schema version %d is newer than supported version %d and there is no %s "+
+			"to restore. Restore a backup made by this version as %s", version, SchemaVersion, backup, dbpath)
+	}
+
+	newer := fmt.Sprintf("%s.v%d.bak", dbpath, version)
+	fmt.Printf("Database schema version %d is newer than supported version %d. Restore %s, backing current "+
+		"database up to %s\n", version, SchemaVersion, backup, newer)
+	if err := copyFile(dbpath, newer); err != nil {
+		return fmt.Errorf("couldn't backup database before restoring %s: %s", backup, err)
+	}
+	if err := copyFile(backup, dbpath); err != nil {
+		return fmt.Errorf("couldn't restore %s: %s", backup, err)
+	}
+	return nil
+}
+
+// migrate upgrades the database at dbpath to SchemaVersion, backing it up first.
+// It refuses to touch a database with a newer schema, which can happen after a revert to a previous version.
+func migrate(db *sql.DB, dbpath string) error {
+	version, err := schemaVersion(db)
+	if err != nil {
+		return err
+	}
+
+	if version > SchemaVersion {
+		return fmt.Errorf("database schema version %d is newer than supported version %d. "+
+			"A backup made before upgrading may be available next to %s", version, SchemaVersion, dbpath)
+	}
+	if version == SchemaVersion {
+		return nil
+	}
+
+	empty, err := isEmpty(db)
+	if err != nil {
+		return err
+	}
+	if !empty {
+		backup := fmt.Sprintf("%s.v%d.bak", dbpath, version)
+		fmt.Println("Backup database to", backup, "before migrating")
+		if err = copyFile(dbpath, backup); err != nil {
+			return fmt.Errorf("couldn't backup database before migrating: %s", err)
+		}
+	}
+
+	for v := version; v < SchemaVersion; v++ {
+		fmt.Printf("Migrate database schema from version %d to %d\n", v, v+1)
+		if err = applyMigration(db, v); err != nil {
+			return fmt.Errorf("couldn't migrate database schema to version %d: %s", v+1, err)
+		}
+	}
+	return nil
+}
+
+func schemaVersion(db *sql.DB) (version int, err error) {
+	err = db.QueryRow("PRAGMA user_version").Scan(&version)
+	return version, err
+}
+
+// applyMigration runs migration v and sets the new schema version in the same transaction
+func applyMigration(db *sql.DB, v int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	if _, err = tx.Exec(migrations[v]); err != nil {
+		tx.Rollback()
+		return err
+	}
+	// pragma doesn't accept bound parameters
+	if _, err = tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", v+1)); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// isEmpty returns true if there is no table yet in the database
+func isEmpty(db *sql.DB) (bool, error) {
+	var n int
+	if err := db.QueryRow("SELECT count(*) FROM sqlite_master WHERE type = 'table'").Scan(&n); err != nil {
+		return false, err
+	}
+	return n == 0, nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tempfile := dst + ".new"
+	out, err := os.Create(tempfile)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempfile)
+
+	if _, err = io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err = out.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tempfile, dst)
+}
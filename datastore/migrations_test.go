@@ -0,0 +1,228 @@
+package datastore
+
+import (
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// baselineSchema is the stats table created by the service before the schema was versioned
+const baselineSchema = `
+	CREATE TABLE IF NOT EXISTS stats(
+		TimeStamp DATETIME,
+		NumPersons INTEGER
+	);
+	`
+
+func TestMigrate(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup []string
+
+		wantErr     bool
+		wantVersion int
+		wantBackup  string
+	}{
+		{"empty database", nil, false, SchemaVersion, ""},
+		{"baseline schema without stats", []string{baselineSchema}, false, SchemaVersion, "storage.db.v0.bak"},
+		{"baseline schema with stats", []string{baselineSchema,
+			"INSERT INTO stats VALUES ('2017-01-02 15:04:05', 2)"}, false, SchemaVersion, "storage.db.v0.bak"},
+		{"intermediate version", []string{migrations[0], migrations[1], "PRAGMA user_version = 2"},
+			false, SchemaVersion, "storage.db.v2.bak"},
+		{"current version", []string{baselineSchema, fmt.Sprintf("PRAGMA user_version = %d", SchemaVersion)},
+			false, SchemaVersion, ""},
+		{"newer version is left untouched", []string{baselineSchema,
+			fmt.Sprintf("PRAGMA user_version = %d", SchemaVersion+1)}, true, SchemaVersion + 1, ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			db, dbpath, cleanup := openTestDB(t, tc.setup)
+			defer cleanup()
+
+			err := migrate(db, dbpath)
+			if tc.wantErr != (err != nil) {
+				t.Fatalf("got error %v, want error: %v", err, tc.wantErr)
+			}
+
+			version, err := schemaVersion(db)
+			if err != nil {
+				t.Fatalf("couldn't get schema version: %s", err)
+			}
+			if version != tc.wantVersion {
+				t.Errorf("got schema version %d, want %d", version, tc.wantVersion)
+			}
+
+			files, err := ioutil.ReadDir(path.Dir(dbpath))
+			if err != nil {
+				t.Fatalf("couldn't list database directory: %s", err)
+			}
+			var backups []string
+			for _, f := range files {
+				if f.Name() != storagefilename {
+					backups = append(backups, f.Name())
+				}
+			}
+			switch {
+			case tc.wantBackup == "" && len(backups) > 0:
+				t.Errorf("got backups %v, want none", backups)
+			case tc.wantBackup != "" && (len(backups) != 1 || backups[0] != tc.wantBackup):
+				t.Errorf("got backups %v, want %s", backups, tc.wantBackup)
+			}
+
+			// migrating again is a no-op
+			if !tc.wantErr {
+				if err = migrate(db, dbpath); err != nil {
+					t.Errorf("couldn't migrate again: %s", err)
+				}
+			}
+		})
+	}
+}
+
+func TestMigrateData(t *testing.T) {
+	// a baseline database with two stats, migrated to version 2 to get detections of the first one on camera 1
+	setup := []string{
+		baselineSchema,
+		"INSERT INTO stats VALUES ('2017-01-02 15:04:05', 1)",
+		"INSERT INTO stats VALUES ('2017-01-02 15:05:05', 0)",
+		migrations[0], migrations[1], "PRAGMA user_version = 2",
+		"INSERT INTO detections VALUES (1, 10, 20, 30, 40, 640, 480, 1, 1)",
+	}
+
+	tests := []struct {
+		name  string
+		query string
+
+		want string
+	}{
+		{"stats are kept", "SELECT group_concat(NumPersons) FROM stats", "1,0"},
+		{"stats take the camera of their detections", "SELECT Camera FROM stats WHERE rowid = 1", "1"},
+		{"stats without detections are attributed to camera 0", "SELECT Camera FROM stats WHERE rowid = 2", "0"},
+		{"rendering mode is converted to a renderer", "SELECT Renderer FROM detections", "fun"},
+		{"detections weren't tracked", "SELECT TrackID FROM detections", "0"},
+		{"tracks start with their stat",
+			"SELECT TrackStart = (SELECT TimeStamp FROM stats WHERE rowid = 1) FROM detections", "1"},
+		{"visits table is created", "SELECT count(*) FROM visits", "0"},
+		{"zone stats table is created", "SELECT count(*) FROM zone_stats", "0"},
+		{"hourly stats table is created", "SELECT count(*) FROM hourly_stats", "0"},
+	}
+
+	db, dbpath, cleanup := openTestDB(t, setup)
+	defer cleanup()
+	if err := migrate(db, dbpath); err != nil {
+		t.Fatalf("couldn't migrate: %s", err)
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var got string
+			if err := db.QueryRow(tc.query).Scan(&got); err != nil {
+				t.Fatalf("query failed: %s", err)
+			}
+			if got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestOpenDBNewerSchema(t *testing.T) {
+	tests := []struct {
+		name   string
+		backup bool
+
+		wantErr bool
+	}{
+		{"backup of current version is restored", true, false},
+		{"no backup to restore", false, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// the backup taken when upgrading from the current version had a stat, removed after the upgrade
+			setup := append(migrations, fmt.Sprintf("PRAGMA user_version = %d", SchemaVersion),
+				"INSERT INTO stats(TimeStamp, NumPersons) VALUES ('2017-01-02 15:04:05', 2)")
+			db, dbpath, cleanup := openTestDB(t, setup)
+			defer cleanup()
+			backup := fmt.Sprintf("%s.v%d.bak", dbpath, SchemaVersion)
+			if tc.backup {
+				if err := copyFile(dbpath, backup); err != nil {
+					t.Fatalf("couldn't create backup: %s", err)
+				}
+			}
+			for _, s := range []string{"DELETE FROM stats", fmt.Sprintf("PRAGMA user_version = %d", SchemaVersion+1)} {
+				if _, err := db.Exec(s); err != nil {
+					t.Fatalf("couldn't upgrade database: %s", err)
+				}
+			}
+			db.Close()
+
+			restored, err := openDB(dbpath)
+			if tc.wantErr {
+				if err == nil {
+					restored.Close()
+					t.Fatal("got no error, want one")
+				}
+				if !strings.Contains(err.Error(), backup) {
+					t.Errorf("got error %q, want it to name %s", err, backup)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			defer restored.Close()
+
+			version, err := schemaVersion(restored)
+			if err != nil {
+				t.Fatalf("couldn't get schema version: %s", err)
+			}
+			if version != SchemaVersion {
+				t.Errorf("got schema version %d, want %d", version, SchemaVersion)
+			}
+			var count int
+			if err = restored.QueryRow("SELECT count(*) FROM stats").Scan(&count); err != nil {
+				t.Fatalf("couldn't count stats: %s", err)
+			}
+			if count != 1 {
+				t.Errorf("got %d stats, want the one of the backup", count)
+			}
+			if _, err = os.Stat(fmt.Sprintf("%s.v%d.bak", dbpath, SchemaVersion+1)); err != nil {
+				t.Errorf("newer database wasn't backed up: %s", err)
+			}
+		})
+	}
+}
+
+// openTestDB creates a database in a temporary directory and runs setup statements on it
+func openTestDB(t *testing.T, setup []string) (*sql.DB, string, func()) {
+	dir, err := ioutil.TempDir("", "face-detection-test")
+	if err != nil {
+		t.Fatalf("couldn't create temporary directory: %s", err)
+	}
+	dbpath := path.Join(dir, storagefilename)
+	db, err := sql.Open("sqlite3", dbpath)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("couldn't open database: %s", err)
+	}
+	cleanup := func() {
+		db.Close()
+		os.RemoveAll(dir)
+	}
+
+	for _, s := range setup {
+		if _, err = db.Exec(s); err != nil {
+			cleanup()
+			t.Fatalf("couldn't set database up with %q: %s", s, err)
+		}
+	}
+	return db, dbpath, cleanup
+}
@@ -0,0 +1,70 @@
+package datastore
+
+import (
+	"fmt"
+	"time"
+)
+
+// retention runs at startup then at this interval in the database goroutine
+const retentionInterval = time.Hour
+
+// applyRetention drops raw stats (and their detections and zone counts) older than the retention policy, storing hourly
+// aggregates of them per camera first if downsampling is enabled.
+func (db *Database) applyRetention() {
+	r := DataRetention()
+	if r.RawDays <= 0 {
+		return
+	}
+
+	// only drop complete hours, so that their aggregate isn't overwritten by a partial one on next run
+	cutoff := time.Now().UTC().AddDate(0, 0, -r.RawDays).Truncate(time.Hour)
+
+	downsamplequery := `
+	INSERT OR REPLACE INTO hourly_stats(Hour, Camera, MinPersons, MaxPersons, AvgPersons, Count)
+	SELECT strftime('%Y-%m-%d %H:00:00', TimeStamp) AS Hour, Camera, MIN(NumPersons), MAX(NumPersons), AVG(NumPersons),
+		COUNT(*)
+	FROM stats
+	WHERE julianday(TimeStamp) < julianday(?)
+	GROUP BY Hour, Camera
+	`
+	deletedetectionsquery := `
+	DELETE FROM detections WHERE StatID IN (SELECT rowid FROM stats WHERE julianday(TimeStamp) < julianday(?))
+	`
+	deletezonesquery := `
+	DELETE FROM zone_stats WHERE StatID IN (SELECT rowid FROM stats WHERE julianday(TimeStamp) < julianday(?))
+	`
+	deletestatsquery := `
+	DELETE FROM stats WHERE julianday(TimeStamp) < julianday(?)
+	`
+
+	tx, err := db.dbconn.Begin()
+	if err != nil {
+		fmt.Println("Couldn't start retention transaction", err)
+		return
+	}
+
+	queries := []string{deletedetectionsquery, deletezonesquery, deletestatsquery}
+	if r.Downsample {
+		queries = append([]string{downsamplequery}, queries...)
+	}
+	var dropped int64
+	for _, q := range queries {
+		res, err := tx.Exec(q, cutoff)
+		if err != nil {
+			fmt.Println("Couldn't apply retention policy:", err)
+			tx.Rollback()
+			return
+		}
+		if q == deletestatsquery {
+			dropped, _ = res.RowsAffected()
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		fmt.Println("Couldn't commit retention policy:", err)
+		return
+	}
+
+	if dropped > 0 {
+		fmt.Printf("Dropped %d stats older than %s\n", dropped, cutoff)
+	}
+}
@@ -0,0 +1,80 @@
+package datastore
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDownsamplingPerCamera(t *testing.T) {
+	db, _, cleanup := openTestDB(t, migrations)
+	defer cleanup()
+	d := Database{dbconn: db}
+
+	settingsmutex.Lock()
+	previous := settings.Retention
+	settings.Retention = Retention{RawDays: 1, Downsample: true}
+	settingsmutex.Unlock()
+	defer func() {
+		settingsmutex.Lock()
+		settings.Retention = previous
+		settingsmutex.Unlock()
+	}()
+
+	hour := time.Now().UTC().AddDate(0, 0, -2).Truncate(time.Hour)
+	for _, s := range []Stat{
+		{TimeStamp: hour.Add(time.Minute), NumPersons: 1, Camera: 0},
+		{TimeStamp: hour.Add(2 * time.Minute), NumPersons: 3, Camera: 0},
+		{TimeStamp: hour.Add(time.Minute), NumPersons: 5, Camera: 1},
+		// recent enough to be kept
+		{TimeStamp: time.Now().UTC(), NumPersons: 2, Camera: 1},
+	} {
+		if d.insertStat(s, nil) == 0 {
+			t.Fatal("couldn't insert stat")
+		}
+	}
+
+	d.applyRetention()
+
+	var left int
+	if err := db.QueryRow("SELECT count(*) FROM stats").Scan(&left); err != nil {
+		t.Fatalf("couldn't count stats: %s", err)
+	}
+	if left != 1 {
+		t.Errorf("got %d stats left, want 1", left)
+	}
+
+	tests := []struct {
+		name   string
+		camera int
+
+		wantMin, wantMax, wantCount int
+		wantAvg                     float64
+	}{
+		{"first camera", 0, 1, 3, 2, 2},
+		{"second camera", 1, 5, 5, 1, 5},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var min, max, count int
+			var avg float64
+			err := db.QueryRow("SELECT MinPersons, MaxPersons, AvgPersons, Count FROM hourly_stats WHERE Camera = ?",
+				tc.camera).Scan(&min, &max, &avg, &count)
+			if err != nil {
+				t.Fatalf("couldn't get hourly stats: %s", err)
+			}
+			if min != tc.wantMin || max != tc.wantMax || avg != tc.wantAvg || count != tc.wantCount {
+				t.Errorf("got min %d, max %d, average %f of %d stats, want min %d, max %d, average %f of %d stats",
+					min, max, avg, count, tc.wantMin, tc.wantMax, tc.wantAvg, tc.wantCount)
+			}
+
+			aggregates, err := d.CameraAggregate(tc.camera, HOURBUCKET, hour, hour.Add(time.Hour))
+			if err != nil {
+				t.Fatalf("couldn't aggregate stats: %s", err)
+			}
+			if len(aggregates) != 1 || aggregates[0].Count != tc.wantCount || aggregates[0].Avg != tc.wantAvg {
+				t.Errorf("got aggregates %v, want one of %d stats averaging %f", aggregates, tc.wantCount, tc.wantAvg)
+			}
+		})
+	}
+}
@@ -1,37 +1,176 @@
 package datastore
 
 import (
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path"
+	"reflect"
 	"sync"
+	"time"
 
 	"github.com/ubuntu/face-detection-demo/appstate"
 
 	"gopkg.in/yaml.v2"
 )
 
-// RenderMode corresponds to various rendering mode options (fun, normal…)
-type RenderMode int
+// Renderer is the name of the renderer drawing detected faces, with its optional parameters
+type Renderer struct {
+	Name   string            `json:"name"`
+	Params map[string]string `json:"params,omitempty"`
+}
 
 const (
 	// NORMALRENDERING draws circles around heads
-	NORMALRENDERING = iota
+	NORMALRENDERING = "normal"
 	// FUNRENDERING draws logos instead on top of heads
-	FUNRENDERING
+	FUNRENDERING = "fun"
+)
+
+// FrameSourceKind corresponds to the type of frame provider detection runs on (camera, video file…)
+type FrameSourceKind string
+
+const (
+	// CAMERASOURCE grabs frames from a webcam
+	CAMERASOURCE FrameSourceKind = "camera"
+	// VIDEOSOURCE replays a video file in loop
+	VIDEOSOURCE FrameSourceKind = "video"
+	// IMAGESSOURCE iterates over still images in a directory
+	IMAGESSOURCE FrameSourceKind = "images"
+	// STREAMSOURCE reads a MJPEG or jpeg snapshot HTTP stream
+	STREAMSOURCE FrameSourceKind = "stream"
+)
+
+// SamplingPolicy corresponds to which grabbed frames are processed by face detection
+type SamplingPolicy string
+
+const (
+	// INTERVALSAMPLING processes one frame every interval
+	INTERVALSAMPLING SamplingPolicy = "interval"
+	// FRAMESSAMPLING processes one frame every FrameStep grabbed frames
+	FRAMESSAMPLING SamplingPolicy = "frames"
+	// MAXFPSSAMPLING processes as many frames as possible, up to MaxFPS per second
+	MAXFPSSAMPLING SamplingPolicy = "maxfps"
+)
+
+// Sampling is the frame sampling policy with its parameters
+type Sampling struct {
+	Policy     SamplingPolicy `json:"policy"`
+	IntervalMs int            `json:"intervalms"`
+	FrameStep  int            `json:"framestep"`
+	MaxFPS     float64        `json:"maxfps"`
+	// MotionThreshold is the average pixel difference ratio (0-1) considered as a scene change
+	MotionThreshold float64 `json:"motionthreshold"`
+	// MotionGate only runs detection on frames selected by the policy if the scene changed since last processed frame
+	MotionGate bool `json:"motiongate"`
+}
+
+// Retention defines how long raw stats are kept
+type Retention struct {
+	// RawDays is the number of days raw stats are kept. 0 keeps them forever
+	RawDays int `json:"rawdays"`
+	// Downsample keeps hourly aggregates of raw stats before dropping them
+	Downsample bool `json:"downsample"`
+}
+
+// DetectorKind is the backend finding faces in frames
+type DetectorKind string
+
+const (
+	// HAARDETECTOR uses OpenCV haar cascades
+	HAARDETECTOR DetectorKind = "haar"
+	// PICODETECTOR uses pixel intensity comparison trees, in pure Go
+	PICODETECTOR DetectorKind = "pico"
+)
+
+// Haar cascade models known by name. Other model names are cascade file names
+const (
+	// FRONTALCASCADE detects faces looking at the camera
+	FRONTALCASCADE = "frontal"
+	// PROFILECASCADE detects faces turned sideways
+	PROFILECASCADE = "profile"
+	// EYESCASCADE detects eyes
+	EYESCASCADE = "eyes"
+	// UPPERBODYCASCADE detects heads and shoulders
+	UPPERBODYCASCADE = "upperbody"
+)
+
+// Cascades are the haar cascade models run on each frame, with their detection parameters
+type Cascades struct {
+	Models []string `json:"models"`
+	// ScaleFactor is how much the searched object size grows at each pass. Should be greater than 1
+	ScaleFactor float64 `json:"scalefactor"`
+	// MinNeighbors is the number of overlapping candidates needed to retain an object
+	MinNeighbors int `json:"minneighbors"`
+	// MinSize and MaxSize bound the size of objects in pixels. 0 means no bound
+	MinSize int `json:"minsize"`
+	MaxSize int `json:"maxsize"`
+	// NMSThreshold is the overlap ratio above which objects are considered the same one and merged
+	NMSThreshold float64 `json:"nmsthreshold"`
+}
+
+// CameraParams are capture settings of a camera. 0 keeps the camera default
+type CameraParams struct {
+	Width  int     `json:"width"`
+	Height int     `json:"height"`
+	FPS    float64 `json:"fps"`
+	// Exposure is passed as is to the camera driver, its unit depending on it. 0 keeps automatic exposure
+	Exposure float64 `json:"exposure"`
+}
+
+// PrivacyMethod is how faces are anonymized in privacy mode
+type PrivacyMethod string
+
+const (
+	// BLURPRIVACY blurs faces
+	BLURPRIVACY PrivacyMethod = "blur"
+	// PIXELATEPRIVACY pixelates faces
+	PIXELATEPRIVACY PrivacyMethod = "pixelate"
 )
 
+// Privacy mode never persists raw frames and anonymizes faces on every saved image
+type Privacy struct {
+	Enabled bool          `json:"enabled"`
+	Method  PrivacyMethod `json:"method"`
+}
+
 type settingsElem struct {
 	FaceDetectionSetting bool
-	RenderingModeSetting RenderMode
+	Renderer             Renderer
 	Camera               int
+	Source               FrameSourceKind
+	SourcePath           string
+	Sampling             Sampling
+	Retention            Retention
+	Privacy              Privacy
+	Detector             DetectorKind
+	Cascades             Cascades
+	Zones                []Zone
+	// Cameras are all cameras detection runs on, the first one being Camera
+	Cameras []int
+	// CameraParams are capture settings by camera number
+	CameraParams map[int]CameraParams
+	// RenderingModeSetting is the numeric rendering mode of previous versions, only read to convert it
+	RenderingModeSetting int `yaml:"renderingmodesetting,omitempty"`
 }
 
 var (
-	settingsdir   string
-	settings      = settingsElem{false, NORMALRENDERING, 0}
-	filesavemutex = &sync.Mutex{}
+	settingsdir     string
+	defaultSampling = Sampling{Policy: INTERVALSAMPLING, IntervalMs: 5000, FrameStep: 10, MaxFPS: 1, MotionThreshold: 0.05}
+	defaultCascades = Cascades{Models: []string{FRONTALCASCADE}, ScaleFactor: 1.1, MinNeighbors: 3, NMSThreshold: 0.3}
+	filesavemutex   = &sync.Mutex{}
+	// settingsmutex protects settings, changed by the main loop while read by detection and server goroutines
+	settingsmutex = &sync.RWMutex{}
+	settings      = settingsElem{
+		Renderer:  Renderer{Name: NORMALRENDERING},
+		Source:    CAMERASOURCE,
+		Sampling:  defaultSampling,
+		Retention: Retention{0, true},
+		Privacy:   Privacy{false, BLURPRIVACY},
+		Detector:  defaultDetector,
+		Cascades:  defaultCascades,
+	}
 )
 
 // initialize directory where data are
@@ -47,25 +186,247 @@ func init() {
 	if err = yaml.Unmarshal(dat, &settings); err != nil {
 		fmt.Println("Couldn't unserialized settings from", settingsdir, ". Reverting to defaults.")
 	}
+	// previous versions had a motion policy, sampling one frame every interval with the motion gate
+	if settings.Sampling.Policy == "motion" {
+		settings.Sampling.Policy = INTERVALSAMPLING
+		settings.Sampling.MotionGate = true
+	}
+	if err = settings.Sampling.Validate(); err != nil {
+		fmt.Println("Invalid sampling settings:", err, ". Reverting to defaults.")
+		settings.Sampling = defaultSampling
+	}
+	if err = settings.Privacy.Validate(); err != nil {
+		fmt.Println("Invalid privacy settings:", err, ". Using blur.")
+		settings.Privacy.Method = BLURPRIVACY
+	}
+	if settings.Detector != HAARDETECTOR && settings.Detector != PICODETECTOR {
+		fmt.Println("Unknown detector", settings.Detector, ". Using", defaultDetector)
+		settings.Detector = defaultDetector
+	}
+	if err = settings.Cascades.Validate(); err != nil {
+		fmt.Println("Invalid cascades settings:", err, ". Reverting to defaults.")
+		settings.Cascades = defaultCascades
+	}
+	var zones []Zone
+	for _, z := range settings.Zones {
+		if zerr := z.Validate(); zerr != nil {
+			fmt.Println("Ignoring invalid zone", z.Name, ":", zerr)
+			continue
+		}
+		zones = append(zones, z)
+	}
+	settings.Zones = zones
+	for c, p := range settings.CameraParams {
+		if err = p.Validate(); err != nil {
+			fmt.Println("Invalid capture settings for camera", c, ":", err, ". Using camera defaults.")
+			delete(settings.CameraParams, c)
+		}
+	}
+	// previous versions only had one camera, which could have been changed since by one of them
+	if len(settings.Cameras) == 0 || settings.Cameras[0] != settings.Camera {
+		settings.Cameras = []int{settings.Camera}
+	}
+	// previous versions only had normal (0) and fun (1) rendering modes
+	if settings.RenderingModeSetting == 1 {
+		settings.Renderer = Renderer{Name: FUNRENDERING}
+	}
+	settings.RenderingModeSetting = 0
 }
 
 // FaceDetection tells if detection is on or off
 func FaceDetection() bool {
+	settingsmutex.RLock()
+	defer settingsmutex.RUnlock()
 	return settings.FaceDetectionSetting
 }
 
-// RenderingMode return current rendering mode
-func RenderingMode() RenderMode {
-	return settings.RenderingModeSetting
+// FaceRenderer return current renderer drawing detected faces
+func FaceRenderer() Renderer {
+	settingsmutex.RLock()
+	defer settingsmutex.RUnlock()
+	return settings.Renderer
 }
 
-// Camera return current camera number set
+// Camera return current camera number set. It's the first one of active cameras
 func Camera() int {
+	settingsmutex.RLock()
+	defer settingsmutex.RUnlock()
 	return settings.Camera
 }
 
+// Cameras return numbers of all active cameras
+func Cameras() []int {
+	settingsmutex.RLock()
+	defer settingsmutex.RUnlock()
+	return settings.Cameras
+}
+
+// CameraParameters return capture settings of camera
+func CameraParameters(camera int) CameraParams {
+	settingsmutex.RLock()
+	defer settingsmutex.RUnlock()
+	return settings.CameraParams[camera]
+}
+
+// AllCameraParameters return capture settings of all cameras which have some
+func AllCameraParameters() map[int]CameraParams {
+	settingsmutex.RLock()
+	defer settingsmutex.RUnlock()
+	return settings.CameraParams
+}
+
+// FrameSource return current frame source kind and its path (file, directory or url)
+func FrameSource() (FrameSourceKind, string) {
+	settingsmutex.RLock()
+	defer settingsmutex.RUnlock()
+	return settings.Source, settings.SourcePath
+}
+
+// FrameSampling return current frame sampling policy and parameters
+func FrameSampling() Sampling {
+	settingsmutex.RLock()
+	defer settingsmutex.RUnlock()
+	return settings.Sampling
+}
+
+// DataRetention return current data retention policy
+func DataRetention() Retention {
+	settingsmutex.RLock()
+	defer settingsmutex.RUnlock()
+	return settings.Retention
+}
+
+// PrivacyMode return current privacy mode
+func PrivacyMode() Privacy {
+	settingsmutex.RLock()
+	defer settingsmutex.RUnlock()
+	return settings.Privacy
+}
+
+// DetectorBackend return current face detector backend
+func DetectorBackend() DetectorKind {
+	settingsmutex.RLock()
+	defer settingsmutex.RUnlock()
+	return settings.Detector
+}
+
+// HaarCascades return current haar cascade models and detection parameters
+func HaarCascades() Cascades {
+	settingsmutex.RLock()
+	defer settingsmutex.RUnlock()
+	return settings.Cascades
+}
+
+// Zones return zones persons are counted in
+func Zones() []Zone {
+	settingsmutex.RLock()
+	defer settingsmutex.RUnlock()
+	return settings.Zones
+}
+
+// Period is the minimum duration between two processed frames for time based policies
+func (s Sampling) Period() time.Duration {
+	if s.Policy == MAXFPSSAMPLING {
+		return time.Duration(float64(time.Second) / s.MaxFPS)
+	}
+	return time.Duration(s.IntervalMs) * time.Millisecond
+}
+
+// Validate checks that sampling parameters are usable
+func (s Sampling) Validate() error {
+	switch s.Policy {
+	case INTERVALSAMPLING, FRAMESSAMPLING, MAXFPSSAMPLING:
+	default:
+		return fmt.Errorf("unknown sampling policy: %s", s.Policy)
+	}
+	if s.IntervalMs <= 0 {
+		return errors.New("capture interval should be positive")
+	}
+	if s.FrameStep < 1 {
+		return errors.New("frame step should be at least 1")
+	}
+	if s.MaxFPS <= 0 {
+		return errors.New("max fps should be positive")
+	}
+	if s.MotionThreshold <= 0 || s.MotionThreshold > 1 {
+		return errors.New("motion threshold should be between 0 and 1")
+	}
+	return nil
+}
+
+// Validate checks that the privacy method is known
+func (p Privacy) Validate() error {
+	switch p.Method {
+	case BLURPRIVACY, PIXELATEPRIVACY:
+		return nil
+	}
+	return fmt.Errorf("unknown privacy method: %s", p.Method)
+}
+
+// Validate checks that cascades detection parameters are usable
+func (c Cascades) Validate() error {
+	if len(c.Models) == 0 {
+		return errors.New("at least one cascade model is needed")
+	}
+	for _, m := range c.Models {
+		if m == "" {
+			return errors.New("cascade model name can't be empty")
+		}
+	}
+	if c.ScaleFactor <= 1 {
+		return errors.New("scale factor should be greater than 1")
+	}
+	if c.MinNeighbors < 0 {
+		return errors.New("min neighbors can't be negative")
+	}
+	if c.MinSize < 0 || c.MaxSize < 0 {
+		return errors.New("min and max sizes can't be negative")
+	}
+	if c.MaxSize != 0 && c.MaxSize < c.MinSize {
+		return errors.New("max size should be greater than min size")
+	}
+	if c.NMSThreshold <= 0 || c.NMSThreshold > 1 {
+		return errors.New("nms threshold should be between 0 and 1")
+	}
+	return nil
+}
+
+// Validate checks that capture settings are usable
+func (p CameraParams) Validate() error {
+	if p.Width < 0 || p.Height < 0 {
+		return errors.New("resolution can't be negative")
+	}
+	if (p.Width == 0) != (p.Height == 0) {
+		return errors.New("both width and height should be set")
+	}
+	if p.FPS < 0 {
+		return errors.New("fps can't be negative")
+	}
+	return nil
+}
+
+// ValidateCameras checks that at least one camera is active, each only once
+func ValidateCameras(cameras []int) error {
+	if len(cameras) == 0 {
+		return errors.New("at least one camera should be active")
+	}
+	seen := make(map[int]bool, len(cameras))
+	for _, c := range cameras {
+		if c < 0 {
+			return errors.New("invalid camera number")
+		}
+		if seen[c] {
+			return errors.New("a camera can't be set more than once")
+		}
+		seen[c] = true
+	}
+	return nil
+}
+
 // SetFaceDetection save new detection state
 func SetFaceDetection(faceDetection bool) {
+	settingsmutex.Lock()
+	defer settingsmutex.Unlock()
 	if faceDetection == settings.FaceDetectionSetting {
 		return
 	}
@@ -74,36 +435,208 @@ func SetFaceDetection(faceDetection bool) {
 	go saveToFile()
 }
 
-// SetRenderingMode save new rendering mode
-func SetRenderingMode(renderingMode RenderMode) {
-	if renderingMode == settings.RenderingModeSetting {
-		return
+// SetFaceRenderer save renderer name and parameters. Return true if anything changed
+func SetFaceRenderer(renderer Renderer) bool {
+	settingsmutex.Lock()
+	defer settingsmutex.Unlock()
+	if reflect.DeepEqual(renderer, settings.Renderer) {
+		return false
 	}
-	settings.RenderingModeSetting = renderingMode
+	settings.Renderer = renderer
 
 	go saveToFile()
+	return true
 }
 
-// SetCamera save active camera number
+// SetCamera save active camera number, which becomes the only active one
 func SetCamera(cameranum int) {
-	if cameranum == settings.Camera {
+	settingsmutex.Lock()
+	defer settingsmutex.Unlock()
+	if cameranum == settings.Camera && len(settings.Cameras) == 1 {
 		return
 	}
 	settings.Camera = cameranum
+	settings.Cameras = []int{cameranum}
+
+	go saveToFile()
+}
+
+// SetCameras save numbers of active cameras. Return true if anything changed
+func SetCameras(cameras []int) bool {
+	settingsmutex.Lock()
+	defer settingsmutex.Unlock()
+	if reflect.DeepEqual(cameras, settings.Cameras) {
+		return false
+	}
+	// cameras are never modified in place as they can be read concurrently
+	settings.Cameras = append([]int(nil), cameras...)
+	settings.Camera = cameras[0]
+
+	go saveToFile()
+	return true
+}
+
+// SetCameraParameters save capture settings of camera. Return true if anything changed
+func SetCameraParameters(camera int, params CameraParams) bool {
+	settingsmutex.Lock()
+	defer settingsmutex.Unlock()
+	if params == settings.CameraParams[camera] {
+		return false
+	}
+	// settings are never modified in place as they can be read concurrently
+	all := make(map[int]CameraParams, len(settings.CameraParams)+1)
+	for c, p := range settings.CameraParams {
+		all[c] = p
+	}
+	if params == (CameraParams{}) {
+		delete(all, camera)
+	} else {
+		all[camera] = params
+	}
+	settings.CameraParams = all
+
+	go saveToFile()
+	return true
+}
+
+// SetFrameSource save frame source kind and path. Return true if anything changed
+func SetFrameSource(kind FrameSourceKind, sourcepath string) bool {
+	settingsmutex.Lock()
+	defer settingsmutex.Unlock()
+	if kind == settings.Source && sourcepath == settings.SourcePath {
+		return false
+	}
+	settings.Source = kind
+	settings.SourcePath = sourcepath
+
+	go saveToFile()
+	return true
+}
+
+// SetFrameSampling save frame sampling policy and parameters. Return true if anything changed
+func SetFrameSampling(sampling Sampling) bool {
+	settingsmutex.Lock()
+	defer settingsmutex.Unlock()
+	if sampling == settings.Sampling {
+		return false
+	}
+	settings.Sampling = sampling
 
 	go saveToFile()
+	return true
+}
+
+// SetDataRetention save data retention policy. Return true if anything changed
+func SetDataRetention(retention Retention) bool {
+	settingsmutex.Lock()
+	defer settingsmutex.Unlock()
+	if retention == settings.Retention {
+		return false
+	}
+	settings.Retention = retention
+
+	go saveToFile()
+	return true
+}
+
+// SetPrivacyMode save privacy mode. Return true if anything changed
+func SetPrivacyMode(privacy Privacy) bool {
+	settingsmutex.Lock()
+	defer settingsmutex.Unlock()
+	if privacy == settings.Privacy {
+		return false
+	}
+	settings.Privacy = privacy
+
+	go saveToFile()
+	return true
+}
+
+// SetDetectorBackend save face detector backend. Return true if it changed
+func SetDetectorBackend(kind DetectorKind) bool {
+	settingsmutex.Lock()
+	defer settingsmutex.Unlock()
+	if kind == settings.Detector {
+		return false
+	}
+	settings.Detector = kind
+
+	go saveToFile()
+	return true
+}
+
+// SetHaarCascades save haar cascade models and detection parameters. Return true if anything changed
+func SetHaarCascades(cascades Cascades) bool {
+	settingsmutex.Lock()
+	defer settingsmutex.Unlock()
+	if reflect.DeepEqual(cascades, settings.Cascades) {
+		return false
+	}
+	settings.Cascades = cascades
+
+	go saveToFile()
+	return true
+}
+
+// SetZone creates zone or replaces the one of the same name. Return true if anything changed
+func SetZone(zone Zone) bool {
+	settingsmutex.Lock()
+	defer settingsmutex.Unlock()
+	// zones are never modified in place as they can be read concurrently
+	zones := make([]Zone, 0, len(settings.Zones)+1)
+	replaced := false
+	for _, z := range settings.Zones {
+		if z.Name != zone.Name {
+			zones = append(zones, z)
+			continue
+		}
+		if reflect.DeepEqual(z, zone) {
+			return false
+		}
+		zones = append(zones, zone)
+		replaced = true
+	}
+	if !replaced {
+		zones = append(zones, zone)
+	}
+	settings.Zones = zones
+
+	go saveToFile()
+	return true
+}
+
+// DeleteZone removes zone name. Return false if there is no such zone
+func DeleteZone(name string) bool {
+	settingsmutex.Lock()
+	defer settingsmutex.Unlock()
+	zones := make([]Zone, 0, len(settings.Zones))
+	for _, z := range settings.Zones {
+		if z.Name != name {
+			zones = append(zones, z)
+		}
+	}
+	if len(zones) == len(settings.Zones) {
+		return false
+	}
+	settings.Zones = zones
+
+	go saveToFile()
+	return true
 }
 
 func saveToFile() {
+	// serialize under the file lock, so that the last written file always has the latest settings
+	filesavemutex.Lock()
+	defer filesavemutex.Unlock()
+
+	settingsmutex.RLock()
 	data, err := yaml.Marshal(&settings)
+	settingsmutex.RUnlock()
 	if err != nil {
-		fmt.Println("Can't convert", settings, "to yaml:", err)
+		fmt.Println("Can't convert settings to yaml:", err)
 		return
 	}
 
-	filesavemutex.Lock()
-	defer filesavemutex.Unlock()
-
 	tempfile := settingsdir + ".new"
 	if err = ioutil.WriteFile(tempfile, data, 0644); err != nil {
 		fmt.Println("Couldn't save settings to", tempfile)
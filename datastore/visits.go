@@ -0,0 +1,126 @@
+package datastore
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Visit is the time a tracked person stayed in sight, from their first to their last detection
+type Visit struct {
+	TrackID int64
+	Camera  int
+	Enter   time.Time
+	Exit    time.Time
+}
+
+// DwellBucket counts visits lasting less than MaxSeconds, and at least the MaxSeconds of previous bucket.
+// MaxSeconds is 0 for the last bucket, without upper bound.
+type DwellBucket struct {
+	MaxSeconds int
+	Count      int
+}
+
+// VisitStats summarizes visits which started in the [From, To] time range. Durations are in seconds
+type VisitStats struct {
+	From         time.Time
+	To           time.Time
+	Visitors     int
+	TotalDwell   float64
+	AvgDwell     float64
+	MedianDwell  float64
+	MaxDwell     float64
+	Distribution []DwellBucket
+}
+
+// upper bounds of dwell distribution buckets, in seconds
+var dwellBuckets = []int{10, 30, 60, 300, 900, 0}
+
+// Dwell is how long the visit lasted
+func (v Visit) Dwell() time.Duration {
+	return v.Exit.Sub(v.Enter)
+}
+
+// AddVisit saves a finished visit to the DB
+func (db *Database) AddVisit(v Visit) {
+	db.newvisit <- v
+}
+
+// VisitsBetween returns all visits which started in the [from, to] time range
+func (db *Database) VisitsBetween(from, to time.Time) (result []Visit, err error) {
+	query := `
+	SELECT TrackID, Camera, Enter, Exit FROM visits
+	WHERE julianday(Enter) BETWEEN julianday(?) AND julianday(?)
+	ORDER BY Enter ASC
+	`
+	rows, err := db.dbconn.Query(query, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		v := Visit{}
+		if err = rows.Scan(&v.TrackID, &v.Camera, &v.Enter, &v.Exit); err != nil {
+			return nil, err
+		}
+		result = append(result, v)
+	}
+	return result, rows.Err()
+}
+
+// VisitStats returns number of visitors and dwell time distribution of visits which started in the [from, to] time
+// range
+func (db *Database) VisitStats(from, to time.Time) (VisitStats, error) {
+	stats := VisitStats{From: from, To: to}
+	for _, max := range dwellBuckets {
+		stats.Distribution = append(stats.Distribution, DwellBucket{MaxSeconds: max})
+	}
+
+	visits, err := db.VisitsBetween(from, to)
+	if err != nil {
+		return stats, err
+	}
+	if len(visits) == 0 {
+		return stats, nil
+	}
+
+	dwells := make([]float64, 0, len(visits))
+	for _, v := range visits {
+		d := v.Dwell().Seconds()
+		dwells = append(dwells, d)
+		stats.TotalDwell += d
+		for i, max := range dwellBuckets {
+			if max == 0 || d < float64(max) {
+				stats.Distribution[i].Count++
+				break
+			}
+		}
+	}
+	sort.Float64s(dwells)
+
+	stats.Visitors = len(visits)
+	stats.AvgDwell = stats.TotalDwell / float64(len(dwells))
+	stats.MaxDwell = dwells[len(dwells)-1]
+	stats.MedianDwell = dwells[len(dwells)/2]
+	if len(dwells)%2 == 0 {
+		stats.MedianDwell = (dwells[len(dwells)/2-1] + dwells[len(dwells)/2]) / 2
+	}
+	return stats, nil
+}
+
+func (db *Database) insertVisit(v Visit) bool {
+	query := `
+	INSERT OR REPLACE INTO visits(
+		TrackID,
+		Camera,
+		Enter,
+		Exit
+	) values(?, ?, ?, ?)
+	`
+	if _, err := db.dbconn.Exec(query, v.TrackID, v.Camera, v.Enter, v.Exit); err != nil {
+		fmt.Println("Couldn't save visit", v, ":", err)
+		return false
+	}
+	return true
+}
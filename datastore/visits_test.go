@@ -0,0 +1,84 @@
+package datastore
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestVisitStats(t *testing.T) {
+	from := time.Date(2017, 1, 2, 15, 0, 0, 0, time.UTC)
+	to := from.Add(time.Hour)
+
+	tests := []struct {
+		name string
+		// dwell time in seconds of visits starting one minute apart from from
+		dwells []float64
+
+		wantVisitors     int
+		wantTotal        float64
+		wantAvg          float64
+		wantMedian       float64
+		wantMax          float64
+		wantDistribution []int
+	}{
+		{"no visit", nil, 0, 0, 0, 0, 0, []int{0, 0, 0, 0, 0, 0}},
+		{"single visit", []float64{42}, 1, 42, 42, 42, 42, []int{0, 0, 1, 0, 0, 0}},
+		{"odd number of visits", []float64{100, 5, 20}, 3, 125, 125.0 / 3, 20, 100, []int{1, 1, 0, 1, 0, 0}},
+		{"even number of visits", []float64{100, 5, 40, 20}, 4, 165, 41.25, 30, 100, []int{1, 1, 1, 1, 0, 0}},
+		{"visits on bucket boundaries", []float64{10, 30, 60, 300, 900, 1000}, 6, 2300, 2300.0 / 6, 180, 1000,
+			[]int{0, 1, 1, 1, 1, 2}},
+		{"visits just below bucket boundaries", []float64{9.5, 29.5, 59.5, 299.5, 899.5}, 5, 1297.5, 259.5, 59.5,
+			899.5, []int{1, 1, 1, 1, 1, 0}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			db, _, cleanup := openTestDB(t, migrations)
+			defer cleanup()
+			d := Database{dbconn: db}
+
+			// visits started out of range aren't counted
+			d.insertVisit(Visit{TrackID: 1000, Enter: from.Add(-time.Minute), Exit: from.Add(time.Minute)})
+			d.insertVisit(Visit{TrackID: 1001, Enter: to.Add(time.Minute), Exit: to.Add(2 * time.Minute)})
+			for i, dwell := range tc.dwells {
+				enter := from.Add(time.Duration(i) * time.Minute)
+				if !d.insertVisit(Visit{TrackID: int64(i + 1), Enter: enter,
+					Exit: enter.Add(time.Duration(dwell * float64(time.Second)))}) {
+					t.Fatal("couldn't insert visit")
+				}
+			}
+
+			stats, err := d.VisitStats(from, to)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if stats.Visitors != tc.wantVisitors {
+				t.Errorf("got %d visitors, want %d", stats.Visitors, tc.wantVisitors)
+			}
+			for _, v := range []struct {
+				name      string
+				got, want float64
+			}{
+				{"total", stats.TotalDwell, tc.wantTotal},
+				{"average", stats.AvgDwell, tc.wantAvg},
+				{"median", stats.MedianDwell, tc.wantMedian},
+				{"max", stats.MaxDwell, tc.wantMax},
+			} {
+				if math.Abs(v.got-v.want) > 1e-6 {
+					t.Errorf("got %s dwell %f, want %f", v.name, v.got, v.want)
+				}
+			}
+
+			if len(stats.Distribution) != len(dwellBuckets) {
+				t.Fatalf("got %d buckets, want %d", len(stats.Distribution), len(dwellBuckets))
+			}
+			for i, b := range stats.Distribution {
+				if b.MaxSeconds != dwellBuckets[i] || b.Count != tc.wantDistribution[i] {
+					t.Errorf("got bucket %d with %d visits below %ds, want %d visits below %ds",
+						i, b.Count, b.MaxSeconds, tc.wantDistribution[i], dwellBuckets[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package datastore
+
+import (
+	"errors"
+	"strings"
+)
+
+// Point is a position in a frame, as ratios (0-1) of its width and height so that it doesn't depend on resolution
+type Point struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// Zone is a named polygonal region of frames in which persons are counted separately
+type Zone struct {
+	Name   string  `json:"name"`
+	Points []Point `json:"points"`
+	// Camera restricts the zone to one camera, offsetted by 1 like for clients. 0 applies it to all frames
+	Camera int `json:"camera,omitempty"`
+}
+
+// Validate checks that the zone has a name and is a polygon inside the frame
+func (z Zone) Validate() error {
+	if strings.TrimSpace(z.Name) == "" {
+		return errors.New("zone name can't be empty")
+	}
+	if z.Camera < 0 {
+		return errors.New("invalid zone camera number")
+	}
+	if len(z.Points) < 3 {
+		return errors.New("a zone needs at least 3 points")
+	}
+	for _, p := range z.Points {
+		if p.X < 0 || p.X > 1 || p.Y < 0 || p.Y > 1 {
+			return errors.New("zone points should be ratios of frame width and height, between 0 and 1")
+		}
+	}
+	return nil
+}
+
+// AppliesTo returns true if persons are counted in the zone on frames of camera (-1 for other frame sources)
+func (z Zone) AppliesTo(camera int) bool {
+	return z.Camera == 0 || z.Camera == camera+1
+}
+
+// Contains returns true if the point at ratios x, y of the frame is inside the zone. Points on left and top edges
+// are inside and those on right and bottom edges outside, so that a point shared by adjacent zones is only in one
+func (z Zone) Contains(x, y float64) bool {
+	// count polygon edges crossed by an horizontal ray going right from the point
+	inside := false
+	for i, j := 0, len(z.Points)-1; i < len(z.Points); j, i = i, i+1 {
+		a, b := z.Points[i], z.Points[j]
+		if (a.Y > y) != (b.Y > y) && x < (b.X-a.X)*(y-a.Y)/(b.Y-a.Y)+a.X {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// addZoneCounts fills number of persons per zone of stats
+func (db *Database) addZoneCounts(stats []Stat) error {
+	if len(stats) == 0 {
+		return nil
+	}
+	minID, maxID := stats[0].ID, stats[0].ID
+	index := make(map[int64]int, len(stats))
+	for i, s := range stats {
+		index[s.ID] = i
+		if s.ID < minID {
+			minID = s.ID
+		}
+		if s.ID > maxID {
+			maxID = s.ID
+		}
+	}
+
+	rows, err := db.dbconn.Query("SELECT StatID, Zone, NumPersons FROM zone_stats WHERE StatID BETWEEN ? AND ?", minID, maxID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var zone string
+		var n int
+		if err = rows.Scan(&id, &zone, &n); err != nil {
+			return err
+		}
+		i, ok := index[id]
+		if !ok {
+			continue
+		}
+		if stats[i].Zones == nil {
+			stats[i].Zones = make(map[string]int)
+		}
+		stats[i].Zones[zone] = n
+	}
+	return rows.Err()
+}
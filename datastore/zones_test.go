@@ -0,0 +1,84 @@
+package datastore
+
+import "testing"
+
+func TestZoneContains(t *testing.T) {
+	square := Zone{Name: "square", Points: []Point{{0, 0}, {1, 0}, {1, 1}, {0, 1}}}
+	triangle := Zone{Name: "triangle", Points: []Point{{0.5, 0}, {1, 1}, {0, 1}}}
+	// U shape, opened at the bottom between 0.25 and 0.75 from half the height
+	u := Zone{Name: "u", Points: []Point{{0, 0}, {1, 0}, {1, 1}, {0.75, 1}, {0.75, 0.5}, {0.25, 0.5}, {0.25, 1}, {0, 1}}}
+
+	tests := []struct {
+		name string
+		zone Zone
+		x, y float64
+
+		want bool
+	}{
+		{"center of square", square, 0.5, 0.5, true},
+		{"outside of square", square, 1.5, 0.5, false},
+		{"left edge of square", square, 0, 0.5, true},
+		{"top edge of square", square, 0.5, 0, true},
+		{"right edge of square", square, 1, 0.5, false},
+		{"bottom edge of square", square, 0.5, 1, false},
+		{"top left vertex of square", square, 0, 0, true},
+		{"top right vertex of square", square, 1, 0, false},
+		{"bottom right vertex of square", square, 1, 1, false},
+		{"bottom left vertex of square", square, 0, 1, false},
+
+		{"inside triangle", triangle, 0.5, 0.75, true},
+		{"outside triangle, next to its top vertex", triangle, 0.25, 0.25, false},
+		{"left edge of triangle", triangle, 0.25, 0.5, true},
+		{"right edge of triangle", triangle, 0.75, 0.5, false},
+		{"top vertex of triangle", triangle, 0.5, 0, false},
+
+		{"left arm of concave zone", u, 0.1, 0.9, true},
+		{"right arm of concave zone", u, 0.9, 0.9, true},
+		{"top of concave zone", u, 0.5, 0.25, true},
+		{"inside the notch of concave zone", u, 0.5, 0.75, false},
+		{"left of concave zone, level with notch vertices", u, 0.1, 0.5, true},
+		{"bottom edge of the notch of concave zone", u, 0.5, 0.5, false},
+		{"left edge of the notch, right of the left arm", u, 0.25, 0.75, false},
+		{"right edge of the notch, left of the right arm", u, 0.75, 0.75, true},
+		{"below concave zone", u, 0.5, 1.5, false},
+
+		{"zone without points", Zone{Name: "empty"}, 0.5, 0.5, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.zone.Contains(tc.x, tc.y); got != tc.want {
+				t.Errorf("got %t, want %t", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAdjacentZonesContain(t *testing.T) {
+	left := Zone{Name: "left", Points: []Point{{0, 0}, {0.5, 0}, {0.5, 1}, {0, 1}}}
+	right := Zone{Name: "right", Points: []Point{{0.5, 0}, {1, 0}, {1, 1}, {0.5, 1}}}
+
+	tests := []struct {
+		name string
+		x, y float64
+
+		wantLeft, wantRight bool
+	}{
+		{"inside left zone", 0.25, 0.5, true, false},
+		{"inside right zone", 0.75, 0.5, false, true},
+		{"on shared edge", 0.5, 0.5, false, true},
+		{"on shared top vertex", 0.5, 0, false, true},
+		{"on shared bottom vertex", 0.5, 1, false, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := left.Contains(tc.x, tc.y); got != tc.wantLeft {
+				t.Errorf("got %t in left zone, want %t", got, tc.wantLeft)
+			}
+			if got := right.Contains(tc.x, tc.y); got != tc.wantRight {
+				t.Errorf("got %t in right zone, want %t", got, tc.wantRight)
+			}
+		})
+	}
+}
@@ -0,0 +1,372 @@
+package detection
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ubuntu/face-detection-demo/comm"
+	"github.com/ubuntu/face-detection-demo/datastore"
+	"github.com/ubuntu/face-detection-demo/messages"
+)
+
+// State is the lifecycle state of detection
+type State string
+
+const (
+	// STOPPED is set when no detection goroutine is running
+	STOPPED State = "stopped"
+	// STARTING is set while frame sources are opened, or while waiting for a camera to be plugged
+	STARTING State = "starting"
+	// RUNNING is set once detection runs on at least one frame source
+	RUNNING State = "running"
+	// STOPPING is set while waiting for detection goroutines to end
+	STOPPING State = "stopping"
+	// FAILED is set when detection couldn't start or stop
+	FAILED State = "failed"
+)
+
+// detection goroutines not ending in this delay once asked to stop are considered stuck
+const stopTimeout = 10 * time.Second
+
+// errWaitingCamera is reported by a detection goroutine waiting for its camera. It doesn't make Start fail
+var errWaitingCamera = errors.New("waiting for camera")
+
+// operation is a queued Start, Stop or Restart request, its outcome being sent to done
+type operation struct {
+	name string
+	run  func() error
+	done chan error
+}
+
+// Controller starts and stops detection goroutines on active cameras, or other frame sources. Start, Stop and
+// Restart requests are queued and run one after another, in the order they were made.
+type Controller struct {
+	rootdir  string
+	shutdown <-chan interface{}
+	wg       *sync.WaitGroup
+
+	// mutex protects fields below
+	mutex sync.Mutex
+	state State
+	err   error
+	// detections in progress, by requested camera number
+	detections map[int]*cameraDetection
+	// camerasChanged is closed, then replaced, when video devices change
+	camerasChanged chan interface{}
+	// operations waiting to be run, wakeup being signalled when one is added
+	operations []operation
+	wakeup     chan interface{}
+	// closed is set on shutdown, once no operation is run anymore
+	closed bool
+}
+
+// NewController creates a stopped controller and the goroutine running its operations. Detection goroutines end on
+// shutdown and are tracked by wg
+func NewController(rootdir string, shutdown <-chan interface{}, wg *sync.WaitGroup) *Controller {
+	c := &Controller{
+		rootdir:        rootdir,
+		shutdown:       shutdown,
+		wg:             wg,
+		state:          STOPPED,
+		detections:     make(map[int]*cameraDetection),
+		camerasChanged: make(chan interface{}),
+		wakeup:         make(chan interface{}, 1),
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-shutdown:
+				c.cancelOperations()
+				return
+			case <-c.wakeup:
+			}
+			for op, ok := c.nextOperation(); ok; op, ok = c.nextOperation() {
+				err := op.run()
+				if err != nil {
+					fmt.Printf("Couldn't %s detection: %s\n", op.name, err)
+				}
+				op.done <- err
+			}
+		}
+	}()
+	return c
+}
+
+// Start starts detection on every active camera (or the frame source set in settings) and returns once they are
+// opened. Detection already started is left untouched. Cameras which aren't available yet are waited for in the
+// background without failing. An error is returned if any frame source couldn't be opened.
+func (c *Controller) Start() error {
+	return <-c.QueueStart()
+}
+
+// Stop disables detection and returns once all detection goroutines ended
+func (c *Controller) Stop() error {
+	return <-c.QueueStop()
+}
+
+// Restart stops detection, then starts it again with current settings. Stopped detection is left untouched
+func (c *Controller) Restart() error {
+	return <-c.QueueRestart()
+}
+
+// QueueStart queues a Start request after pending ones, returning without waiting for it. Its outcome is sent to
+// the returned channel
+func (c *Controller) QueueStart() <-chan error {
+	return c.queue("start", c.start)
+}
+
+// QueueStop queues a Stop request after pending ones, returning without waiting for it. Its outcome is sent to the
+// returned channel
+func (c *Controller) QueueStop() <-chan error {
+	return c.queue("stop", func() error {
+		datastore.SetFaceDetection(false)
+		comm.WSserv.SendAllClients(&messages.WSMessage{
+			Type:          "facedetection",
+			FaceDetection: datastore.FaceDetection(),
+		})
+		return c.stop()
+	})
+}
+
+// QueueRestart queues a Restart request after pending ones, returning without waiting for it. Its outcome is sent
+// to the returned channel
+func (c *Controller) QueueRestart() <-chan error {
+	return c.queue("restart", func() error {
+		// detection may have been stopped since the restart was requested
+		if state, _ := c.State(); state == STOPPED {
+			return nil
+		}
+		if err := c.stop(); err != nil {
+			return err
+		}
+		return c.start()
+	})
+}
+
+// State returns the current state of detection, with the error which made it fail
+func (c *Controller) State() (State, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.state, c.err
+}
+
+// Running returns true if a frame source is opened and detection is running on it
+func (c *Controller) Running() bool {
+	return len(c.RunningCameras()) > 0
+}
+
+// RunningCameras returns numbers of cameras detection is running on, -1 standing for other frame sources
+func (c *Controller) RunningCameras() []int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	var cameras []int
+	for _, d := range c.detections {
+		if d.running {
+			cameras = append(cameras, d.camera)
+		}
+	}
+	sort.Ints(cameras)
+	return cameras
+}
+
+func (c *Controller) queue(name string, run func() error) <-chan error {
+	done := make(chan error, 1)
+	c.mutex.Lock()
+	if c.closed {
+		c.mutex.Unlock()
+		done <- fmt.Errorf("can't %s detection: shutting down", name)
+		return done
+	}
+	c.operations = append(c.operations, operation{name: name, run: run, done: done})
+	c.mutex.Unlock()
+	select {
+	case c.wakeup <- nil:
+	default:
+	}
+	return done
+}
+
+// nextOperation pops the oldest queued operation
+func (c *Controller) nextOperation() (operation, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if len(c.operations) == 0 {
+		return operation{}, false
+	}
+	op := c.operations[0]
+	c.operations = c.operations[1:]
+	return op, true
+}
+
+// cancelOperations fails operations which won't be run on shutdown
+func (c *Controller) cancelOperations() {
+	c.mutex.Lock()
+	c.closed = true
+	c.mutex.Unlock()
+	for op, ok := c.nextOperation(); ok; op, ok = c.nextOperation() {
+		op.done <- fmt.Errorf("can't %s detection: shutting down", op.name)
+	}
+}
+
+func (c *Controller) start() error {
+	if state, _ := c.State(); state == STARTING || state == RUNNING {
+		fmt.Println("Detection command received but already started")
+		return nil
+	}
+	// detections which didn't stop in time would capture from the same cameras
+	if err := c.waitDetections(); err != nil {
+		c.setState(FAILED, err)
+		return err
+	}
+	c.setState(STARTING, nil)
+
+	cameras := []int{-1}
+	if kindIsCamera() {
+		cameras = datastore.Cameras()
+	}
+	ready := make(chan error, len(cameras))
+	c.mutex.Lock()
+	for _, camera := range cameras {
+		c.startDetection(camera, len(cameras) == 1, ready)
+	}
+	c.mutex.Unlock()
+
+	// each detection goroutine reports once if it's running, waiting for its camera or failed
+	var running, waiting bool
+	var failures []string
+	for range cameras {
+		switch err := <-ready; err {
+		case nil:
+			running = true
+		case errWaitingCamera:
+			waiting = true
+		default:
+			failures = append(failures, err.Error())
+		}
+	}
+
+	var err error
+	if len(failures) > 0 {
+		err = errors.New(strings.Join(failures, ", "))
+	}
+	if running || waiting {
+		// detection stays enabled while waiting for a camera, so that it's started again after a service restart
+		enableFaceDetection()
+	}
+	switch {
+	case running:
+		c.setState(RUNNING, nil)
+	case waiting:
+		// goroutines waiting for their camera will switch to running
+	default:
+		c.setState(FAILED, err)
+	}
+	return err
+}
+
+func (c *Controller) stop() error {
+	switch state, _ := c.State(); state {
+	case STOPPED:
+		fmt.Println("Turning off detection command received but not started")
+		return nil
+	case FAILED:
+		// failing to start leaves no detection goroutine behind
+		c.mutex.Lock()
+		n := len(c.detections)
+		c.mutex.Unlock()
+		if n == 0 {
+			c.setState(STOPPED, nil)
+			return nil
+		}
+	}
+	c.setState(STOPPING, nil)
+
+	c.mutex.Lock()
+	for _, d := range c.detections {
+		d.end()
+	}
+	c.mutex.Unlock()
+
+	if err := c.waitDetections(); err != nil {
+		c.setState(FAILED, err)
+		return err
+	}
+	c.setState(STOPPED, nil)
+	return nil
+}
+
+// waitDetections waits for detection goroutines, already asked to stop, to end
+func (c *Controller) waitDetections() error {
+	c.mutex.Lock()
+	var done []chan interface{}
+	for _, d := range c.detections {
+		done = append(done, d.done)
+	}
+	c.mutex.Unlock()
+
+	timeout := time.After(stopTimeout)
+	for _, ch := range done {
+		select {
+		case <-ch:
+		case <-timeout:
+			return fmt.Errorf("detection didn't stop after %s", stopTimeout)
+		}
+	}
+	return nil
+}
+
+// setState changes the state of detection and notifies clients if it changed
+func (c *Controller) setState(state State, err error) {
+	c.mutex.Lock()
+	changed := state != c.state || err != c.err
+	c.state, c.err = state, err
+	c.mutex.Unlock()
+	if !changed {
+		return
+	}
+
+	msg := &messages.WSMessage{Type: "detectionstate", DetectionState: string(state)}
+	if err != nil {
+		msg.DetectionError = err.Error()
+	}
+	fmt.Println("Detection state changed to", state, msg.DetectionError)
+	comm.WSserv.SendAllClients(msg)
+}
+
+// detectionRunning switches detection to running once a detection goroutine opened its frame source
+func (c *Controller) detectionRunning() {
+	if state, _ := c.State(); state == STARTING {
+		c.setState(RUNNING, nil)
+	}
+}
+
+// detectionEnded forgets a detection goroutine once it ended, failing if it wasn't reported to Start. Detection
+// is stopped once all of them ended on shutdown, other cases being handled by Start and Stop
+func (c *Controller) detectionEnded(camera int, d *cameraDetection, err error) {
+	c.mutex.Lock()
+	// a detection which didn't stop in time may have been replaced since
+	if c.detections[camera] == d {
+		delete(c.detections, camera)
+	}
+	n := len(c.detections)
+	c.mutex.Unlock()
+	if n > 0 {
+		return
+	}
+
+	select {
+	case <-c.shutdown:
+		c.setState(STOPPED, nil)
+	default:
+		if err != nil {
+			c.setState(FAILED, err)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package detection
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/ubuntu/face-detection-demo/datastore"
+)
+
+// Detector finds faces on a frame
+type Detector interface {
+	// Detect returns bounding boxes of faces found on frame
+	Detect(frame image.Image) []image.Rectangle
+	// Release frees any resources held by the detector
+	Release()
+}
+
+// NewDetector loads the detector backend of given kind, with its model files from rootdir
+func NewDetector(kind datastore.DetectorKind, rootdir string) (Detector, error) {
+	switch kind {
+	case datastore.HAARDETECTOR:
+		return newHaarDetector(rootdir)
+	case datastore.PICODETECTOR:
+		return newPicoDetector(rootdir)
+	}
+	return nil, fmt.Errorf("unknown face detector: %s", kind)
+}
@@ -0,0 +1,93 @@
+//go:build !noopencv
+// +build !noopencv
+
+package detection
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/lazywei/go-opencv/opencv"
+	"github.com/ubuntu/face-detection-demo/datastore"
+)
+
+// cascade files of named models
+var cascadeFiles = map[string]string{
+	datastore.FRONTALCASCADE:   "frontfacedetection.xml",
+	datastore.PROFILECASCADE:   "haarcascade_profileface.xml",
+	datastore.EYESCASCADE:      "haarcascade_eye.xml",
+	datastore.UPPERBODYCASCADE: "haarcascade_upperbody.xml",
+}
+
+// cascade files are looked up in root directory, then in opencv data, shipped in the snap or installed on the system
+var cascadeDirs = []string{"", "usr/share/opencv/haarcascades", "/usr/share/opencv/haarcascades"}
+
+// haarDetector runs opencv haar cascades of all models set in settings and merges their results
+type haarDetector struct {
+	cascades []*haarCascade
+	params   datastore.Cascades
+}
+
+func newHaarDetector(rootdir string) (*haarDetector, error) {
+	d := &haarDetector{params: datastore.HaarCascades()}
+	for _, model := range d.params.Models {
+		// skip unavailable models, as long as one of them can be used
+		cascade, err := loadHaarCascade(cascadeFile(rootdir, model))
+		if err != nil {
+			fmt.Printf("Can't load %s haar cascade: %s\n", model, err)
+			continue
+		}
+		d.cascades = append(d.cascades, cascade)
+	}
+	if len(d.cascades) == 0 {
+		return nil, errors.New("no haar cascade could be loaded")
+	}
+	return d, nil
+}
+
+// cascadeFile returns the path of a model cascade file, looking it up in cascade directories
+func cascadeFile(rootdir string, model string) string {
+	name, ok := cascadeFiles[model]
+	if !ok {
+		name = model
+	}
+	if filepath.IsAbs(name) {
+		return name
+	}
+
+	for _, dir := range cascadeDirs {
+		if !filepath.IsAbs(dir) {
+			dir = path.Join(rootdir, dir)
+		}
+		f := path.Join(dir, name)
+		if _, err := os.Stat(f); err == nil {
+			return f
+		}
+	}
+	return path.Join(rootdir, name)
+}
+
+// Detect runs each cascade in models order: objects of first models are kept over overlapping ones
+func (d *haarDetector) Detect(frame image.Image) []image.Rectangle {
+	img := opencv.FromImage(frame)
+	if img == nil {
+		return nil
+	}
+	defer img.Release()
+
+	var objects []image.Rectangle
+	for _, cascade := range d.cascades {
+		objects = append(objects, cascade.detect(img, d.params)...)
+	}
+	return nonMaxSuppression(objects, d.params.NMSThreshold)
+}
+
+func (d *haarDetector) Release() {
+	for _, cascade := range d.cascades {
+		cascade.release()
+	}
+}
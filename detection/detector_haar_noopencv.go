@@ -0,0 +1,10 @@
+//go:build noopencv
+// +build noopencv
+
+package detection
+
+import "errors"
+
+func newHaarDetector(rootdir string) (Detector, error) {
+	return nil, errors.New("haar detector needs opencv support, use pico detector instead")
+}
@@ -0,0 +1,181 @@
+package detection
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"io/ioutil"
+	"math"
+	"path"
+	"sort"
+)
+
+// pico detector parameters
+const (
+	picoMinSize     = 20
+	picoShiftFactor = 0.1
+	picoScaleFactor = 1.1
+	// detections overlapping more than this are merged
+	picoIoUThreshold = 0.2
+	// minimum score of merged detections to be considered as faces
+	picoMinQuality = 5.0
+)
+
+// picoDetector is a pure go implementation of pico (Pixel Intensity Comparison-based Object detection).
+// It runs a cascade of binary decision trees, each node comparing the intensity of two pixels.
+type picoDetector struct {
+	treeDepth     uint32
+	treeNum       uint32
+	treeCodes     []int8
+	treePreds     []float32
+	treeThreshold []float32
+}
+
+type picoDetection struct {
+	row, col, scale int
+	q               float32
+}
+
+func newPicoDetector(rootdir string) (*picoDetector, error) {
+	cascadefile := path.Join(rootdir, "facefinder")
+	data, err := ioutil.ReadFile(cascadefile)
+	if err != nil {
+		return nil, fmt.Errorf("can't load pico cascade: %s", err)
+	}
+	d, err := unpackPicoCascade(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pico cascade %s: %s", cascadefile, err)
+	}
+	return d, nil
+}
+
+// unpackPicoCascade decodes a binary pico cascade: 8 ignored bytes, the depth and number of trees, then for each
+// tree its node codes, leaf predictions and threshold. All values are little endian.
+func unpackPicoCascade(data []byte) (*picoDetector, error) {
+	errTruncated := errors.New("truncated cascade file")
+	if len(data) < 16 {
+		return nil, errTruncated
+	}
+	d := &picoDetector{
+		treeDepth: binary.LittleEndian.Uint32(data[8:]),
+		treeNum:   binary.LittleEndian.Uint32(data[12:]),
+	}
+	if d.treeDepth == 0 || d.treeDepth > 16 {
+		return nil, fmt.Errorf("unsupported tree depth: %d", d.treeDepth)
+	}
+	pos := 16
+	leaves := 1 << d.treeDepth
+	treeSize := 4*leaves - 4 + 4*leaves + 4
+	if uint64(len(data)-pos) < uint64(d.treeNum)*uint64(treeSize) {
+		return nil, errTruncated
+	}
+
+	for t := 0; t < int(d.treeNum); t++ {
+		// root node is at index 1: pad codes so that node i codes start at 4*i
+		d.treeCodes = append(d.treeCodes, 0, 0, 0, 0)
+		for _, c := range data[pos : pos+4*leaves-4] {
+			d.treeCodes = append(d.treeCodes, int8(c))
+		}
+		pos += 4*leaves - 4
+		for i := 0; i < leaves; i++ {
+			d.treePreds = append(d.treePreds, math.Float32frombits(binary.LittleEndian.Uint32(data[pos:])))
+			pos += 4
+		}
+		d.treeThreshold = append(d.treeThreshold, math.Float32frombits(binary.LittleEndian.Uint32(data[pos:])))
+		pos += 4
+	}
+	return d, nil
+}
+
+func (d *picoDetector) Detect(frame image.Image) []image.Rectangle {
+	b := frame.Bounds()
+	pixels := grayPixels(frame)
+	rows, cols := b.Dy(), b.Dx()
+	maxSize := rows
+	if cols < maxSize {
+		maxSize = cols
+	}
+
+	var detections []picoDetection
+	for scale := picoMinSize; scale <= maxSize; scale = int(float64(scale) * picoScaleFactor) {
+		step := int(math.Max(picoShiftFactor*float64(scale), 1))
+		offset := scale/2 + 1
+		for row := offset; row <= rows-offset; row += step {
+			for col := offset; col <= cols-offset; col += step {
+				if q := d.classifyRegion(row, col, scale, pixels, cols); q > 0 {
+					detections = append(detections, picoDetection{row, col, scale, q})
+				}
+			}
+		}
+	}
+
+	var faces []image.Rectangle
+	for _, det := range clusterPicoDetections(detections) {
+		if det.q < picoMinQuality {
+			continue
+		}
+		faces = append(faces, det.rect().Add(b.Min))
+	}
+	return faces
+}
+
+func (d *picoDetector) Release() {}
+
+// classifyRegion runs the cascade on the square region centered on row, col. It returns a positive score if the
+// region contains a face.
+func (d *picoDetector) classifyRegion(row, col, scale int, pixels []uint8, stride int) float32 {
+	leaves := 1 << d.treeDepth
+	root := 0
+	var out float32
+
+	// codes are relative offsets in 1/256 of scale
+	row, col = row*256, col*256
+	for i := 0; i < int(d.treeNum); i++ {
+		idx := 1
+		for j := 0; j < int(d.treeDepth); j++ {
+			c := d.treeCodes[root+4*idx:]
+			p1 := ((row+int(c[0])*scale)>>8)*stride + ((col + int(c[1])*scale) >> 8)
+			p2 := ((row+int(c[2])*scale)>>8)*stride + ((col + int(c[3])*scale) >> 8)
+			idx = 2 * idx
+			if pixels[p1] <= pixels[p2] {
+				idx++
+			}
+		}
+		out += d.treePreds[leaves*i+idx-leaves]
+		if out <= d.treeThreshold[i] {
+			return -1
+		}
+		root += 4 * leaves
+	}
+	return out - d.treeThreshold[d.treeNum-1]
+}
+
+// clusterPicoDetections merges overlapping detections, averaging their position and summing their score
+func clusterPicoDetections(detections []picoDetection) []picoDetection {
+	sort.Slice(detections, func(i, j int) bool { return detections[i].q > detections[j].q })
+
+	var clusters []picoDetection
+	assigned := make([]bool, len(detections))
+	for i := range detections {
+		if assigned[i] {
+			continue
+		}
+		var row, col, scale, n int
+		var q float32
+		for j := i; j < len(detections); j++ {
+			if assigned[j] || iou(detections[i].rect(), detections[j].rect()) <= picoIoUThreshold {
+				continue
+			}
+			assigned[j] = true
+			row, col, scale, n = row+detections[j].row, col+detections[j].col, scale+detections[j].scale, n+1
+			q += detections[j].q
+		}
+		clusters = append(clusters, picoDetection{row / n, col / n, scale / n, q})
+	}
+	return clusters
+}
+
+func (d picoDetection) rect() image.Rectangle {
+	return image.Rect(d.col-d.scale/2, d.row-d.scale/2, d.col+d.scale/2, d.row+d.scale/2)
+}
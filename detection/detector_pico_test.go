@@ -0,0 +1,195 @@
+package detection
+
+import (
+	"encoding/binary"
+	"image"
+	"image/png"
+	"math"
+	"os"
+	"testing"
+)
+
+// picoTree is a cascade tree for tests: codes of its nodes, leaf predictions and threshold
+type picoTree struct {
+	codes     []int8
+	preds     []float32
+	threshold float32
+}
+
+// packPicoCascade encodes trees the way pico cascade files are
+func packPicoCascade(depth uint32, trees []picoTree) []byte {
+	data := make([]byte, 16)
+	binary.LittleEndian.PutUint32(data[8:], depth)
+	binary.LittleEndian.PutUint32(data[12:], uint32(len(trees)))
+	buf := make([]byte, 4)
+	for _, t := range trees {
+		for _, c := range t.codes {
+			data = append(data, byte(c))
+		}
+		for _, p := range t.preds {
+			binary.LittleEndian.PutUint32(buf, math.Float32bits(p))
+			data = append(data, buf...)
+		}
+		binary.LittleEndian.PutUint32(buf, math.Float32bits(t.threshold))
+		data = append(data, buf...)
+	}
+	return data
+}
+
+// squareCascade finds regions whose center is brighter than their top and bottom edges
+var squareCascade = []picoTree{
+	{codes: []int8{0, 0, -115, 0}, preds: []float32{1, -1}, threshold: 0},
+	{codes: []int8{0, 0, 115, 0}, preds: []float32{1, -1}, threshold: 1.5},
+}
+
+func TestUnpackPicoCascade(t *testing.T) {
+	valid := packPicoCascade(1, squareCascade)
+	deep := packPicoCascade(2, []picoTree{
+		{codes: []int8{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}, preds: []float32{0.5, -0.5, 1, -1}, threshold: -2},
+	})
+
+	tests := []struct {
+		name string
+		data []byte
+
+		wantErr       bool
+		wantDepth     uint32
+		wantNum       uint32
+		wantCodes     []int8
+		wantPreds     []float32
+		wantThreshold []float32
+	}{
+		{"two trees of depth 1", valid, false, 1, 2,
+			[]int8{0, 0, 0, 0, 0, 0, -115, 0, 0, 0, 0, 0, 0, 0, 115, 0},
+			[]float32{1, -1, 1, -1}, []float32{0, 1.5}},
+		{"one tree of depth 2", deep, false, 2, 1,
+			[]int8{0, 0, 0, 0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12},
+			[]float32{0.5, -0.5, 1, -1}, []float32{-2}},
+		{"no tree", packPicoCascade(3, nil), false, 3, 0, nil, nil, nil},
+		{"empty", nil, true, 0, 0, nil, nil, nil},
+		{"truncated header", valid[:12], true, 0, 0, nil, nil, nil},
+		{"truncated tree", valid[:len(valid)-1], true, 0, 0, nil, nil, nil},
+		{"null depth", packPicoCascade(0, nil), true, 0, 0, nil, nil, nil},
+		{"too deep", packPicoCascade(17, nil), true, 0, 0, nil, nil, nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := unpackPicoCascade(tc.data)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected an error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if d.treeDepth != tc.wantDepth || d.treeNum != tc.wantNum {
+				t.Errorf("got depth %d and %d trees, want depth %d and %d trees",
+					d.treeDepth, d.treeNum, tc.wantDepth, tc.wantNum)
+			}
+			if !equalInt8s(d.treeCodes, tc.wantCodes) {
+				t.Errorf("got codes %v, want %v", d.treeCodes, tc.wantCodes)
+			}
+			if !equalFloat32s(d.treePreds, tc.wantPreds) {
+				t.Errorf("got predictions %v, want %v", d.treePreds, tc.wantPreds)
+			}
+			if !equalFloat32s(d.treeThreshold, tc.wantThreshold) {
+				t.Errorf("got thresholds %v, want %v", d.treeThreshold, tc.wantThreshold)
+			}
+		})
+	}
+}
+
+func TestPicoDetect(t *testing.T) {
+	d, err := unpackPicoCascade(packPicoCascade(1, squareCascade))
+	if err != nil {
+		t.Fatalf("couldn't unpack cascade: %s", err)
+	}
+	square := image.Rect(30, 30, 70, 70)
+
+	tests := []struct {
+		name  string
+		frame image.Image
+
+		wantFaces bool
+	}{
+		{"bright square", loadFixture(t, "testdata/square.png"), true},
+		{"bright square with offsetted bounds", offsetted(loadFixture(t, "testdata/square.png"), 10, 20), true},
+		{"blank frame", image.NewGray(image.Rect(0, 0, 100, 100)), false},
+		{"frame smaller than min size", image.NewGray(image.Rect(0, 0, picoMinSize-1, picoMinSize-1)), false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			faces := d.Detect(tc.frame)
+			if !tc.wantFaces {
+				if len(faces) != 0 {
+					t.Errorf("expected no face, got %v", faces)
+				}
+				return
+			}
+			if len(faces) == 0 {
+				t.Fatal("expected faces, got none")
+			}
+			// faces are centered on the square, in frame coordinates
+			b := tc.frame.Bounds()
+			for _, f := range faces {
+				center := f.Min.Add(f.Max).Div(2).Sub(b.Min)
+				if !center.In(square) {
+					t.Errorf("face %v isn't centered on %v", f, square.Add(b.Min))
+				}
+			}
+		})
+	}
+}
+
+func loadFixture(t *testing.T, name string) image.Image {
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatalf("couldn't open fixture: %s", err)
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatalf("couldn't decode fixture: %s", err)
+	}
+	return img
+}
+
+// offsetted copies img to a frame whose bounds start at x, y
+func offsetted(img image.Image, x, y int) image.Image {
+	b := img.Bounds()
+	dst := image.NewGray(b.Add(image.Pt(x, y)))
+	for j := b.Min.Y; j < b.Max.Y; j++ {
+		for i := b.Min.X; i < b.Max.X; i++ {
+			dst.Set(i+x, j+y, img.At(i, j))
+		}
+	}
+	return dst
+}
+
+func equalInt8s(a, b []int8) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func equalFloat32s(a, b []float32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,307 @@
+package detection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	// decoders for images read from directories and streams
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ubuntu/face-detection-demo/datastore"
+)
+
+// FrameSource provides frames to run face detection on
+type FrameSource interface {
+	// GrabFrame fetches next frame from the source, returning false if none is available
+	GrabFrame() bool
+	// RetrieveFrame returns last grabbed frame, or nil if it can't be decoded
+	RetrieveFrame() image.Image
+	// Live sources produce frames continuously and need to be drained even if not processed
+	Live() bool
+	// Release frees any resources held by the source
+	Release()
+}
+
+// interrupter is implemented by frame sources whose GrabFrame can block for long. Interrupt makes any current and
+// later grab return right away, and is safe to call from another goroutine
+type interrupter interface {
+	Interrupt()
+}
+
+var imageExtensions = []string{".jpg", ".jpeg", ".png"}
+
+// NewFrameSource opens a frame source of given kind. path is ignored for cameras
+func NewFrameSource(kind datastore.FrameSourceKind, sourcepath string, cameraNum int) (FrameSource, error) {
+	switch kind {
+	case datastore.CAMERASOURCE:
+		return newCameraSource(cameraNum)
+	case datastore.VIDEOSOURCE:
+		return newVideoSource(sourcepath)
+	case datastore.IMAGESSOURCE:
+		return newImagesSource(sourcepath)
+	case datastore.STREAMSOURCE:
+		return newStreamSource(sourcepath)
+	}
+	return nil, fmt.Errorf("unknown frame source type: %s", kind)
+}
+
+/*
+ * Directory of still images, iterated in name order and in loop. One image is consumed per processed frame.
+ */
+
+type imagesSource struct {
+	files []string
+	next  int
+	img   image.Image
+}
+
+func newImagesSource(dir string) (*imagesSource, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("can't read image directory %s: %s", dir, err)
+	}
+
+	var files []string
+	for _, e := range entries {
+		if e.IsDir() || !isImageFile(e.Name()) {
+			continue
+		}
+		files = append(files, path.Join(dir, e.Name()))
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no image found in %s", dir)
+	}
+	sort.Strings(files)
+
+	return &imagesSource{files: files}, nil
+}
+
+func isImageFile(filename string) bool {
+	ext := strings.ToLower(path.Ext(filename))
+	for _, e := range imageExtensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *imagesSource) GrabFrame() bool {
+	// skip undecodable files, but don't loop forever if none is valid
+	for i := 0; i < len(s.files); i++ {
+		f := s.files[s.next]
+		s.next = (s.next + 1) % len(s.files)
+		img, err := loadImage(f)
+		if err == nil {
+			s.img = img
+			return true
+		}
+		fmt.Println("Couldn't load image", f, ":", err)
+	}
+	s.img = nil
+	return false
+}
+
+func (s *imagesSource) RetrieveFrame() image.Image { return s.img }
+func (s *imagesSource) Live() bool                 { return false }
+func (s *imagesSource) Release()                   { s.img = nil }
+
+func loadImage(filepath string) (image.Image, error) {
+	f, err := os.Open(filepath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	return img, err
+}
+
+/*
+ * HTTP stream: either MJPEG (multipart/x-mixed-replace) or a single jpeg snapshot url fetched on each grab
+ */
+
+const (
+	// stalled stream servers can't block a grab longer than these
+	streamConnectTimeout = 3 * time.Second
+	streamReadTimeout    = 5 * time.Second
+)
+
+// streamClient doesn't set an overall timeout, MJPEG responses never ending: reads are bounded by streamReadTimeout
+var streamClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           (&net.Dialer{Timeout: streamConnectTimeout}).DialContext,
+		TLSHandshakeTimeout:   streamConnectTimeout,
+		ResponseHeaderTimeout: streamReadTimeout,
+	},
+}
+
+type streamSource struct {
+	url string
+	// multipart is true for MJPEG streams, false for snapshot urls
+	multipart bool
+	body      io.ReadCloser
+	parts     *multipart.Reader
+	img       image.Image
+
+	// ctx is cancelled by Interrupt, cancelConn by a read stalled for streamReadTimeout
+	ctx        context.Context
+	cancel     context.CancelFunc
+	mutex      sync.Mutex
+	cancelConn context.CancelFunc
+}
+
+func newStreamSource(url string) (*streamSource, error) {
+	if url == "" {
+		return nil, errors.New("no stream url provided")
+	}
+	s := &streamSource{url: url}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	if err := s.connect(); err != nil {
+		s.cancel()
+		return nil, err
+	}
+	return s, nil
+}
+
+// connect to the stream url. For MJPEG streams, the connection is kept open and parts are read one after another
+func (s *streamSource) connect() error {
+	s.disconnect()
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	req, err := http.NewRequest("GET", s.url, nil)
+	if err != nil {
+		cancel()
+		return fmt.Errorf("invalid stream url %s: %s", s.url, err)
+	}
+	resp, err := streamClient.Do(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return fmt.Errorf("can't connect to stream %s: %s", s.url, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		cancel()
+		return fmt.Errorf("can't connect to stream %s: %s", s.url, resp.Status)
+	}
+
+	mediatype, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil {
+		resp.Body.Close()
+		cancel()
+		return fmt.Errorf("invalid content type from stream %s: %s", s.url, err)
+	}
+
+	s.mutex.Lock()
+	s.cancelConn = cancel
+	s.mutex.Unlock()
+	s.body = &stallReader{body: resp.Body, s: s}
+	s.multipart = strings.HasPrefix(mediatype, "multipart/")
+	if s.multipart {
+		s.parts = multipart.NewReader(s.body, params["boundary"])
+	}
+	return nil
+}
+
+func (s *streamSource) disconnect() {
+	if s.body != nil {
+		s.body.Close()
+	}
+	s.body = nil
+	s.parts = nil
+	s.mutex.Lock()
+	if s.cancelConn != nil {
+		s.cancelConn()
+		s.cancelConn = nil
+	}
+	s.mutex.Unlock()
+}
+
+// stallReader drops the connection if a read doesn't return in streamReadTimeout
+type stallReader struct {
+	body io.ReadCloser
+	s    *streamSource
+}
+
+func (r *stallReader) Read(p []byte) (int, error) {
+	r.s.mutex.Lock()
+	cancel := r.s.cancelConn
+	r.s.mutex.Unlock()
+	if cancel == nil {
+		return 0, errors.New("stream disconnected")
+	}
+	timer := time.AfterFunc(streamReadTimeout, cancel)
+	defer timer.Stop()
+	return r.body.Read(p)
+}
+
+func (r *stallReader) Close() error { return r.body.Close() }
+
+func (s *streamSource) GrabFrame() bool {
+	s.img = nil
+
+	img, err := s.nextImage()
+	if err != nil {
+		// not connected or connection dropped: reconnect once before giving up on this frame
+		if err = s.connect(); err == nil {
+			img, err = s.nextImage()
+		}
+	}
+	if err != nil {
+		fmt.Println("Couldn't read frame from stream:", err)
+		s.disconnect()
+		return false
+	}
+
+	s.img = img
+	return true
+}
+
+func (s *streamSource) nextImage() (image.Image, error) {
+	if s.body == nil {
+		return nil, errors.New("not connected")
+	}
+
+	// jpeg snapshot url: next grab will reconnect to fetch a new image
+	if !s.multipart {
+		img, _, err := image.Decode(s.body)
+		s.disconnect()
+		return img, err
+	}
+
+	part, err := s.parts.NextPart()
+	if err != nil {
+		return nil, err
+	}
+	defer part.Close()
+	img, _, err := image.Decode(part)
+	return img, err
+}
+
+func (s *streamSource) RetrieveFrame() image.Image { return s.img }
+
+// Live is only true for MJPEG streams: snapshot urls are fetched when a frame is due
+func (s *streamSource) Live() bool { return s.multipart }
+
+// Interrupt cancels any current request and prevents new ones
+func (s *streamSource) Interrupt() { s.cancel() }
+
+func (s *streamSource) Release() {
+	s.img = nil
+	s.disconnect()
+	s.cancel()
+}
@@ -0,0 +1,13 @@
+//go:build noopencv
+// +build noopencv
+
+package detection
+
+import "errors"
+
+var errNoOpenCV = errors.New("built without opencv support")
+
+// cameras and video files are decoded by opencv
+func newCameraSource(cameraNum int) (FrameSource, error)  { return nil, errNoOpenCV }
+func newVideoSource(filepath string) (FrameSource, error) { return nil, errNoOpenCV }
+func cameraAvailable(i int) bool                          { return false }
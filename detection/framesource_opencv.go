@@ -0,0 +1,150 @@
+//go:build !noopencv
+// +build !noopencv
+
+package detection
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"time"
+
+	"github.com/lazywei/go-opencv/opencv"
+	"github.com/ubuntu/face-detection-demo/datastore"
+)
+
+const defaultVideoFPS = 25
+
+// opencv capture properties not exposed by the go binding
+const (
+	capPropExposure = 15
+)
+
+/*
+ * Camera
+ */
+
+type cameraSource struct {
+	cap *opencv.Capture
+}
+
+// newCameraSource opens camera cameraNum, applying its capture settings. Unsupported ones are ignored by the driver
+func newCameraSource(cameraNum int) (*cameraSource, error) {
+	cap := opencv.NewCameraCapture(cameraNum)
+	if cap == nil {
+		return nil, fmt.Errorf("can't open camera %d", cameraNum)
+	}
+
+	params := datastore.CameraParameters(cameraNum)
+	if params.Width > 0 {
+		cap.SetProperty(opencv.CV_CAP_PROP_FRAME_WIDTH, float64(params.Width))
+		cap.SetProperty(opencv.CV_CAP_PROP_FRAME_HEIGHT, float64(params.Height))
+	}
+	if params.FPS > 0 {
+		cap.SetProperty(opencv.CV_CAP_PROP_FPS, params.FPS)
+	}
+	if params.Exposure != 0 {
+		cap.SetProperty(capPropExposure, params.Exposure)
+	}
+	fmt.Printf("Camera %d opened at %gx%g\n", cameraNum,
+		cap.GetProperty(opencv.CV_CAP_PROP_FRAME_WIDTH), cap.GetProperty(opencv.CV_CAP_PROP_FRAME_HEIGHT))
+	return &cameraSource{cap}, nil
+}
+
+func (s *cameraSource) GrabFrame() bool            { return s.cap.GrabFrame() }
+func (s *cameraSource) RetrieveFrame() image.Image { return toImage(s.cap.RetrieveFrame(1)) }
+func (s *cameraSource) Live() bool                 { return true }
+func (s *cameraSource) Release()                   { s.cap.Release() }
+
+// cameraAvailable returns true if camera i can be opened
+func cameraAvailable(i int) bool {
+	cap := opencv.NewCameraCapture(i)
+	if cap == nil {
+		return false
+	}
+	cap.Release()
+	return true
+}
+
+/*
+ * Video file, replayed in loop at its own frame rate to behave like a camera
+ */
+
+type videoSource struct {
+	filepath  string
+	cap       *opencv.Capture
+	frameTime time.Duration
+	nextFrame time.Time
+}
+
+func newVideoSource(filepath string) (*videoSource, error) {
+	if filepath == "" {
+		return nil, errors.New("no video file provided")
+	}
+	s := &videoSource{filepath: filepath}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// open (re)opens the video file. The capture is left nil on failure
+func (s *videoSource) open() error {
+	s.cap = opencv.NewFileCapture(s.filepath)
+	if s.cap == nil {
+		return fmt.Errorf("can't open video file %s", s.filepath)
+	}
+	fps := s.cap.GetProperty(opencv.CV_CAP_PROP_FPS)
+	if fps <= 0 {
+		fps = defaultVideoFPS
+	}
+	s.frameTime = time.Duration(float64(time.Second) / fps)
+	return nil
+}
+
+func (s *videoSource) GrabFrame() bool {
+	// pace reading to the video frame rate
+	time.Sleep(s.nextFrame.Sub(time.Now()))
+	s.nextFrame = time.Now().Add(s.frameTime)
+
+	// the file couldn't be reopened last time: it may be back
+	if s.cap == nil {
+		if err := s.open(); err != nil {
+			fmt.Println("Couldn't read frame from video:", err)
+			return false
+		}
+	}
+	if s.cap.GrabFrame() {
+		return true
+	}
+
+	// end of file: restart from the beginning
+	s.cap.Release()
+	if err := s.open(); err != nil {
+		fmt.Println("Couldn't read frame from video:", err)
+		return false
+	}
+	return s.cap.GrabFrame()
+}
+
+func (s *videoSource) RetrieveFrame() image.Image {
+	if s.cap == nil {
+		return nil
+	}
+	return toImage(s.cap.RetrieveFrame(1))
+}
+func (s *videoSource) Live() bool                 { return true }
+
+func (s *videoSource) Release() {
+	if s.cap != nil {
+		s.cap.Release()
+	}
+}
+
+// toImage copies an opencv frame, owned by its capture, to a go image
+func toImage(img *opencv.IplImage) image.Image {
+	if img == nil {
+		return nil
+	}
+	return img.ToImage()
+}
@@ -0,0 +1,83 @@
+//go:build !noopencv
+// +build !noopencv
+
+package detection
+
+/*
+#cgo linux pkg-config: opencv
+#include <stdlib.h>
+#include <opencv/cv.h>
+
+static CvHaarClassifierCascade* load_cascade(const char* filename) {
+	return (CvHaarClassifierCascade*)cvLoad(filename, NULL, NULL, NULL);
+}
+
+static void release_cascade(CvHaarClassifierCascade* cascade) {
+	cvReleaseHaarClassifierCascade(&cascade);
+}
+
+// detect_objects runs cascade on img and copies at most maxrects found objects to rects. Return their number.
+static int detect_objects(IplImage* img, CvHaarClassifierCascade* cascade, double scale_factor, int min_neighbors,
+		int min_size, int max_size, CvRect* rects, int maxrects) {
+	CvMemStorage* storage = cvCreateMemStorage(0);
+	CvSeq* seq = cvHaarDetectObjects(img, cascade, storage, scale_factor, min_neighbors, CV_HAAR_DO_CANNY_PRUNING,
+		cvSize(min_size, min_size), cvSize(max_size, max_size));
+	int n = 0;
+	for (; seq != NULL && n < seq->total && n < maxrects; n++) {
+		rects[n] = *(CvRect*)cvGetSeqElem(seq, n);
+	}
+	cvReleaseMemStorage(&storage);
+	return n;
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"unsafe"
+
+	"github.com/lazywei/go-opencv/opencv"
+	"github.com/ubuntu/face-detection-demo/datastore"
+)
+
+// maximum number of objects returned by one cascade on a frame
+const maxCascadeObjects = 256
+
+// haarCascade is an opencv haar cascade. go-opencv binding doesn't enable tuning detection parameters
+type haarCascade struct {
+	cascade *C.CvHaarClassifierCascade
+}
+
+func loadHaarCascade(filename string) (*haarCascade, error) {
+	// opencv aborts on unreadable files
+	if _, err := os.Stat(filename); err != nil {
+		return nil, err
+	}
+	cfilename := C.CString(filename)
+	defer C.free(unsafe.Pointer(cfilename))
+
+	cascade := C.load_cascade(cfilename)
+	if cascade == nil {
+		return nil, fmt.Errorf("%s isn't a haar cascade", filename)
+	}
+	return &haarCascade{cascade}, nil
+}
+
+// detect returns objects found by the cascade on img
+func (h *haarCascade) detect(img *opencv.IplImage, p datastore.Cascades) []image.Rectangle {
+	rects := make([]C.CvRect, maxCascadeObjects)
+	n := C.detect_objects((*C.IplImage)(unsafe.Pointer(img)), h.cascade, C.double(p.ScaleFactor), C.int(p.MinNeighbors),
+		C.int(p.MinSize), C.int(p.MaxSize), &rects[0], C.int(len(rects)))
+
+	objects := make([]image.Rectangle, 0, int(n))
+	for _, r := range rects[:n] {
+		objects = append(objects, image.Rect(int(r.x), int(r.y), int(r.x+r.width), int(r.y+r.height)))
+	}
+	return objects
+}
+
+func (h *haarCascade) release() {
+	C.release_cascade(h.cascade)
+}
@@ -0,0 +1,154 @@
+package detection
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ubuntu/face-detection-demo/appstate"
+	"github.com/ubuntu/face-detection-demo/comm"
+	"github.com/ubuntu/face-detection-demo/datastore"
+	"github.com/ubuntu/face-detection-demo/messages"
+	"github.com/ubuntu/face-detection-demo/metrics"
+)
+
+const (
+	// how often video devices are listed to notice cameras being plugged or unplugged
+	hotplugInterval = 2 * time.Second
+	// a camera without any grabbed frame for this long is considered unplugged
+	cameraLostTimeout = 5 * time.Second
+	// delays before grabbing again after failed grabs, doubling from min to max, so that an unplugged camera or a
+	// stream which is down doesn't spin
+	grabRetryDelay    = 100 * time.Millisecond
+	grabRetryMaxDelay = 5 * time.Second
+	// delays between attempts to reopen a lost camera, doubling from min to max
+	reopenMinDelay = 1 * time.Second
+	reopenMaxDelay = 30 * time.Second
+)
+
+// videoDevicesGlob matches video devices, their number being the camera index
+var videoDevicesGlob = "/dev/video*"
+
+// WatchCameras creates a go routine listing video devices periodically. When they change, available cameras are
+// detected again and sent to clients
+func (c *Controller) WatchCameras(shutdown <-chan interface{}, wg *sync.WaitGroup) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		devices := videoDevices()
+		for {
+			select {
+			case <-shutdown:
+				return
+			case <-time.After(hotplugInterval):
+			}
+
+			current := videoDevices()
+			if reflect.DeepEqual(current, devices) {
+				continue
+			}
+			fmt.Println("Video devices changed from", devices, "to", current)
+			devices = current
+
+			cameras := probeCameras(c.RunningCameras())
+			infos := describeCameras(cameras)
+			// detections waiting for their camera try opening it right away
+			c.notifyCamerasChanged()
+			if reflect.DeepEqual(infos, appstate.CameraInfos()) {
+				continue
+			}
+			appstate.SetAvailableCameras(cameras, infos)
+			comm.WSserv.SendAllClients(&messages.WSMessage{
+				Type:             "availablecameras",
+				AvailableCameras: cameras,
+				CameraInfos:      infos})
+			c.UpdateCameraState()
+		}
+	}()
+}
+
+// notifyCamerasChanged wakes up detections waiting for their camera
+func (c *Controller) notifyCamerasChanged() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	close(c.camerasChanged)
+	c.camerasChanged = make(chan interface{})
+}
+
+// videoDevices returns sorted indexes of video devices
+func videoDevices() []int {
+	paths, err := filepath.Glob(videoDevicesGlob)
+	if err != nil {
+		return nil
+	}
+	prefix := strings.TrimSuffix(videoDevicesGlob, "*")
+	var devices []int
+	for _, p := range paths {
+		if i, err := strconv.Atoi(strings.TrimPrefix(p, prefix)); err == nil {
+			devices = append(devices, i)
+		}
+	}
+	sort.Ints(devices)
+	return devices
+}
+
+// reopen retries opening the camera with an increasing delay, or as soon as video devices change, until it
+// succeeds or detection is stopped (then returning nil). A single camera fails over to any other available one.
+func (d *cameraDetection) reopen(single bool) FrameSource {
+	delay := reopenMinDelay
+	for {
+		d.ctrl.mutex.Lock()
+		changed := d.ctrl.camerasChanged
+		d.ctrl.mutex.Unlock()
+
+		candidates := []int{d.camera}
+		if single {
+			for _, c := range appstate.AvailableCameras() {
+				// cameras are offsetted by 1 for the client
+				if c-1 != d.camera {
+					candidates = append(candidates, c-1)
+				}
+			}
+		}
+
+		for _, c := range candidates {
+			source, err := newCameraSource(c)
+			if err != nil {
+				metrics.CameraOpenFailures.Inc()
+				continue
+			}
+			if c != d.camera {
+				fmt.Printf("Camera %d unavailable. Failing over to camera %d\n", d.camera, c)
+				d.setCamera(c)
+				datastore.SetCamera(c)
+				comm.WSserv.SendAllClients(&messages.WSMessage{
+					Type: "newcameraactivated",
+					// camera is offsetted by 1 for the client
+					Camera:        c + 1,
+					ActiveCameras: messages.ClientCameras(datastore.Cameras())})
+			}
+			return source
+		}
+
+		d.setFailure(fmt.Sprintf("can't open camera %d", d.camera+1))
+		fmt.Printf("Can't open camera %d. Retrying in %s\n", d.camera, delay)
+		select {
+		case <-d.stop:
+			return nil
+		case <-changed:
+			delay = reopenMinDelay
+			continue
+		case <-time.After(delay):
+		}
+		delay *= 2
+		if delay > reopenMaxDelay {
+			delay = reopenMaxDelay
+		}
+	}
+}
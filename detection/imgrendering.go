@@ -1,6 +1,7 @@
 package detection
 
 import (
+	"errors"
 	"fmt"
 	"image"
 	"image/draw"
@@ -8,9 +9,9 @@ import (
 	"log"
 	"os"
 	"path"
+	"strconv"
+	"sync"
 
-	"github.com/lazywei/go-opencv/opencv"
-	"github.com/nfnt/resize"
 	"github.com/ubuntu/face-detection-demo/appstate"
 	"github.com/ubuntu/face-detection-demo/datastore"
 )
@@ -20,21 +21,29 @@ var (
 	logosPath = []string{"ubuntu.png", "archlinux.png", "debian.png", "gentoo.png",
 		"fedora.png", "opensuse.png", "yocto.png", "smiley.png"}
 	datadir string
-
-	detectedfilename = "screendetected.png"
-	screenshotname   = "screencapture.png"
+	// screenshotsMutex is held for reading while saving a screenshot, from checking privacy mode to renaming it,
+	// and for writing while wiping them. Screenshots checked before enabling privacy are then always wiped
+	screenshotsMutex sync.RWMutex
 )
 
-// RenderedImage abstract if we are using opencv or direct image blending
+// RenderedImage is a copy of a frame on which detected faces are drawn by Renderer, with Zones outlines.
+// It's saved to Filename in data directory
 type RenderedImage struct {
-	cvimg         *opencvImg
-	img           *rgbaImg
-	RenderingMode datastore.RenderMode
+	img      *rgbaImg
+	Renderer datastore.Renderer
+	Zones    []datastore.Zone
+	Filename string
 }
 
-type opencvImg opencv.IplImage
+// rawImg is a frame as grabbed from the source
+type rawImg struct {
+	image.Image
+}
 type rgbaImg struct {
 	*image.RGBA
+	// faces detected on the image. hidden[i] is true once faces[i] is anonymized
+	faces  []image.Rectangle
+	hidden []bool
 }
 type saver interface {
 	Save(string) error
@@ -68,14 +77,11 @@ func init() {
 	logos = logos[:i]
 }
 
-// Save opencv images
-func (i *opencvImg) Save(filepath string) error {
-	opencv.SaveImage(filepath, (*opencv.IplImage)(i), nil)
-	return nil
-}
-
-// Save rgba images in png
-func (i *rgbaImg) Save(filepath string) error {
+// Save raw frames in png. They are never saved in privacy mode as their faces can't be anonymized
+func (i rawImg) Save(filepath string) error {
+	if datastore.PrivacyMode().Enabled {
+		return errors.New("raw frames are not saved in privacy mode")
+	}
 	f, err := os.Create(filepath)
 	if err != nil {
 		return err
@@ -84,69 +90,89 @@ func (i *rgbaImg) Save(filepath string) error {
 	return png.Encode(f, i)
 }
 
-// DrawFace renders a new face on top of image depending on rendering type
-func (r *RenderedImage) DrawFace(face *opencv.Rect, num int, cvimage *opencv.IplImage) {
+// newRGBAImg copies a frame
+func newRGBAImg(source image.Image) *rgbaImg {
+	i := &rgbaImg{RGBA: image.NewRGBA(source.Bounds())}
+	draw.Draw(i, i.Bounds(), source, source.Bounds().Min, draw.Src)
+	return i
+}
 
-	if appstate.BrokenMode {
-		// force drawing smileys instead of people
-		r.drawFunFace(face, len(logos)-1, cvimage)
+// addFace records a detected face, anonymizing it right away in privacy mode
+func (i *rgbaImg) addFace(face image.Rectangle) {
+	i.faces = append(i.faces, face)
+	i.hidden = append(i.hidden, false)
+	i.anonymize()
+}
+
+// anonymize faces which aren't yet if privacy mode is enabled
+func (i *rgbaImg) anonymize() {
+	privacy := datastore.PrivacyMode()
+	if !privacy.Enabled {
 		return
 	}
-
-	switch r.RenderingMode {
-	case datastore.NORMALRENDERING:
-		if r.cvimg == nil {
-			r.cvimg = (*opencvImg)(cvimage.Clone())
+	renderer, ok := renderers[string(privacy.Method)]
+	if !ok {
+		renderer = renderers[string(datastore.BLURPRIVACY)]
+	}
+	for n, face := range i.faces {
+		if i.hidden[n] {
+			continue
 		}
-
-		opencv.Circle((*opencv.IplImage)(r.cvimg),
-			opencv.Point{
-				X: face.X() + (face.Width() / 2),
-				Y: face.Y() + (face.Height() / 2),
-			},
-			face.Width()/2,
-			opencv.ScalarAll(255.0), 1, 1, 0)
-
-	case datastore.FUNRENDERING:
-		// TODO: logo needs to be randomized depending on num
-		r.drawFunFace(face, num%(len(logos)-1), cvimage)
+		// cover a slightly bigger area than detected and make blocks or blur proportional to the face
+		size := strconv.Itoa(face.Dx()/6 + 1)
+		renderer.DrawFace(i.RGBA, face.Inset(-face.Dx()/10), n, map[string]string{"radius": size, "size": size})
+		i.hidden[n] = true
 	}
 }
 
-func (r *RenderedImage) drawFunFace(face *opencv.Rect, num int, cvimage *opencv.IplImage) {
+// Save rgba images in png. Faces are anonymized first in privacy mode
+func (i *rgbaImg) Save(filepath string) error {
+	i.anonymize()
+	f, err := os.Create(filepath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return png.Encode(f, i)
+}
 
+// DrawFace renders a new face on top of image with the renderer. In privacy mode, the face is anonymized first
+func (r *RenderedImage) DrawFace(face image.Rectangle, num int, frame image.Image) {
 	if r.img == nil {
-		source := cvimage.ToImage()
-		r.img = &rgbaImg{image.NewRGBA(source.Bounds())}
-		draw.Draw(r.img, r.img.Bounds(), source, image.ZP, draw.Src)
+		r.img = newRGBAImg(frame)
 	}
+	r.img.addFace(face)
 
-	// resize logo to match face
-	logo := resize.Resize(0, uint(face.Height()), logos[num], resize.NearestNeighbor)
-	logorect := image.Rect(face.X()+face.Width()/2-logo.Bounds().Dx()/2,
-		face.Y()+face.Height()/2-logo.Bounds().Dy()/2,
-		face.X()+logo.Bounds().Dx(),
-		face.Y()+logo.Bounds().Dy())
+	name, params := r.Renderer.Name, r.Renderer.Params
+	if appstate.BrokenMode {
+		// force drawing smileys instead of people
+		name, params = brokenRenderer, nil
+	}
+	renderer, ok := renderers[name]
+	if !ok {
+		fmt.Println("Unknown renderer", name, ", fallback to", datastore.NORMALRENDERING)
+		renderer = renderers[datastore.NORMALRENDERING]
+	}
 
-	draw.Draw(r.img, logorect, logo, image.ZP, draw.Over)
+	renderer.DrawFace(r.img.RGBA, face, num, params)
 }
 
-// Save current image in destination file
+// Save current image in destination file, with zones drawn on top
 func (r *RenderedImage) Save() {
-
-	var i saver
-	if r.cvimg != nil {
-		i = r.cvimg
-	} else {
-		i = r.img
+	if r.img == nil {
+		return
 	}
-
-	if err := saveatomic(datadir, detectedfilename, i); err != nil {
+	drawZones(r.img.RGBA, r.Zones)
+	if err := saveatomic(datadir, r.Filename, r.img); err != nil {
 		fmt.Println(err)
 	}
 }
 
+// saveatomic saves s through a temporary file. All images are written through savers, which enforce privacy mode
 func saveatomic(dir string, filename string, s saver) error {
+	screenshotsMutex.RLock()
+	defer screenshotsMutex.RUnlock()
+
 	tempfilen := path.Join(dir, "new"+filename)
 	dstfilen := path.Join(dir, filename)
 
@@ -161,8 +187,15 @@ func saveatomic(dir string, filename string, s saver) error {
 	return nil
 }
 
-// WipeScreenshots removes screenshots in dir unconditionally (existing or not)
+// WipeScreenshots removes screenshots of all cameras in dir unconditionally (existing or not), once those being
+// saved are written
 func WipeScreenshots(dir string) {
-	os.Remove(path.Join(dir, detectedfilename))
-	os.Remove(path.Join(dir, screenshotname))
+	screenshotsMutex.Lock()
+	defer screenshotsMutex.Unlock()
+	for _, name := range []string{appstate.DetectedFilename, appstate.ScreenshotFilename} {
+		os.Remove(path.Join(dir, name))
+		for i := 0; i < 10; i++ {
+			os.Remove(path.Join(dir, appstate.CameraFilename(name, i)))
+		}
+	}
 }
@@ -0,0 +1,48 @@
+package detection
+
+import "image"
+
+// objects mostly inside another one are merged with it, like a face inside an upper body
+const containedRatio = 0.8
+
+// nonMaxSuppression merges overlapping objects, found for instance by different models. Objects are ordered by
+// priority: the first one of overlapping objects is kept.
+func nonMaxSuppression(objects []image.Rectangle, threshold float64) []image.Rectangle {
+	var kept []image.Rectangle
+	for _, o := range objects {
+		overlaps := false
+		for _, k := range kept {
+			if iou(o, k) > threshold || containment(o, k) > containedRatio {
+				overlaps = true
+				break
+			}
+		}
+		if !overlaps {
+			kept = append(kept, o)
+		}
+	}
+	return kept
+}
+
+// iou is the intersection over union ratio of two rectangles
+func iou(a, b image.Rectangle) float64 {
+	inter := a.Intersect(b)
+	if inter.Empty() {
+		return 0
+	}
+	i := float64(inter.Dx() * inter.Dy())
+	return i / (float64(a.Dx()*a.Dy()+b.Dx()*b.Dy()) - i)
+}
+
+// containment is the ratio of the smallest rectangle area inside the other one
+func containment(a, b image.Rectangle) float64 {
+	inter := a.Intersect(b)
+	if inter.Empty() {
+		return 0
+	}
+	smallest := a.Dx() * a.Dy()
+	if s := b.Dx() * b.Dy(); s < smallest {
+		smallest = s
+	}
+	return float64(inter.Dx()*inter.Dy()) / float64(smallest)
+}
@@ -0,0 +1,92 @@
+package detection
+
+import (
+	"image"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestIoU(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b image.Rectangle
+
+		want float64
+	}{
+		{"identical", image.Rect(0, 0, 10, 10), image.Rect(0, 0, 10, 10), 1},
+		{"disjoint", image.Rect(0, 0, 10, 10), image.Rect(20, 20, 30, 30), 0},
+		{"touching edges", image.Rect(0, 0, 10, 10), image.Rect(10, 0, 20, 10), 0},
+		{"half overlapping", image.Rect(0, 0, 10, 10), image.Rect(5, 0, 15, 10), 1.0 / 3},
+		{"contained", image.Rect(0, 0, 10, 10), image.Rect(0, 0, 5, 5), 0.25},
+		{"symmetric", image.Rect(0, 0, 5, 5), image.Rect(0, 0, 10, 10), 0.25},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := iou(tc.a, tc.b); math.Abs(got-tc.want) > 1e-9 {
+				t.Errorf("got %f, want %f", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestContainment(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b image.Rectangle
+
+		want float64
+	}{
+		{"contained", image.Rect(0, 0, 100, 100), image.Rect(10, 10, 50, 50), 1},
+		{"containing", image.Rect(10, 10, 50, 50), image.Rect(0, 0, 100, 100), 1},
+		{"half overlapping", image.Rect(0, 0, 10, 10), image.Rect(5, 0, 15, 10), 0.5},
+		{"corner of the smallest", image.Rect(0, 0, 10, 10), image.Rect(8, 8, 12, 12), 0.25},
+		{"disjoint", image.Rect(0, 0, 10, 10), image.Rect(20, 20, 30, 30), 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := containment(tc.a, tc.b); math.Abs(got-tc.want) > 1e-9 {
+				t.Errorf("got %f, want %f", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNonMaxSuppression(t *testing.T) {
+	a := image.Rect(0, 0, 10, 10)
+	halfA := image.Rect(5, 0, 15, 10)
+	nextToA := image.Rect(10, 0, 20, 10)
+	slightlyOverA := image.Rect(6, 0, 16, 10)
+	body := image.Rect(0, 0, 100, 100)
+	face := image.Rect(10, 10, 50, 50)
+
+	tests := []struct {
+		name      string
+		objects   []image.Rectangle
+		threshold float64
+
+		want []image.Rectangle
+	}{
+		{"no object", nil, 0.3, nil},
+		{"single object", []image.Rectangle{a}, 0.3, []image.Rectangle{a}},
+		{"disjoint objects are kept in order", []image.Rectangle{nextToA, a}, 0.3, []image.Rectangle{nextToA, a}},
+		{"duplicate is merged", []image.Rectangle{a, a}, 0.3, []image.Rectangle{a}},
+		{"overlap above threshold keeps the first one", []image.Rectangle{halfA, a}, 0.3, []image.Rectangle{halfA}},
+		{"overlap below threshold keeps both", []image.Rectangle{a, slightlyOverA}, 0.3,
+			[]image.Rectangle{a, slightlyOverA}},
+		{"overlap at threshold keeps both", []image.Rectangle{a, halfA}, 1.0 / 3, []image.Rectangle{a, halfA}},
+		{"contained face is merged with its body", []image.Rectangle{body, face}, 0.3, []image.Rectangle{body}},
+		{"body is merged with the contained face", []image.Rectangle{face, body}, 0.3, []image.Rectangle{face}},
+		{"only compared to kept objects", []image.Rectangle{a, halfA, nextToA}, 0.3, []image.Rectangle{a, nextToA}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := nonMaxSuppression(tc.objects, tc.threshold); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package detection
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"sort"
+	"strconv"
+
+	"github.com/nfnt/resize"
+	"github.com/ubuntu/face-detection-demo/appstate"
+	"github.com/ubuntu/face-detection-demo/datastore"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// Renderer draws a detected face on a frame
+type Renderer interface {
+	// DrawFace renders face on img, with optional renderer parameters. num identifies the person, it's the same
+	// on all frames they are tracked on
+	DrawFace(img *image.RGBA, face image.Rectangle, num int, params map[string]string)
+}
+
+// RendererFunc is a function usable as a Renderer
+type RendererFunc func(img *image.RGBA, face image.Rectangle, num int, params map[string]string)
+
+// DrawFace calls f
+func (f RendererFunc) DrawFace(img *image.RGBA, face image.Rectangle, num int, params map[string]string) {
+	f(img, face, num, params)
+}
+
+var renderers = make(map[string]Renderer)
+
+// renderer forced in broken mode
+const brokenRenderer = "emoji"
+
+func init() {
+	RegisterRenderer(datastore.NORMALRENDERING, RendererFunc(drawCircle))
+	RegisterRenderer(datastore.FUNRENDERING, RendererFunc(drawLogo))
+	RegisterRenderer("emoji", RendererFunc(drawSmiley))
+	RegisterRenderer("blur", RendererFunc(drawBlur))
+	RegisterRenderer("pixelate", RendererFunc(drawPixelated))
+	RegisterRenderer("box", RendererFunc(drawBox))
+	RegisterRenderer("anonymize", RendererFunc(drawFilled))
+}
+
+// RegisterRenderer makes a renderer available under name, replacing any existing one with the same name
+func RegisterRenderer(name string, r Renderer) {
+	if _, exists := renderers[name]; !exists {
+		appstate.AvailableRenderers = append(appstate.AvailableRenderers, name)
+		sort.Strings(appstate.AvailableRenderers)
+	}
+	renderers[name] = r
+}
+
+// ValidateRenderer returns an error if no renderer is registered under name
+func ValidateRenderer(name string) error {
+	if _, ok := renderers[name]; !ok {
+		return fmt.Errorf("unknown renderer: %s", name)
+	}
+	return nil
+}
+
+// drawCircle draws a circle around the head. Parameters: color (#rrggbb), thickness in pixels
+func drawCircle(img *image.RGBA, face image.Rectangle, num int, params map[string]string) {
+	c := colorParam(params, "color", color.RGBA{255, 255, 255, 255})
+	thickness := intParam(params, "thickness", 1)
+
+	center := image.Pt(face.Min.X+face.Dx()/2, face.Min.Y+face.Dy()/2)
+	outer := face.Dx() / 2
+	inner := outer - thickness
+	for y := center.Y - outer; y <= center.Y+outer; y++ {
+		for x := center.X - outer; x <= center.X+outer; x++ {
+			d := (x-center.X)*(x-center.X) + (y-center.Y)*(y-center.Y)
+			if d <= outer*outer && d > inner*inner {
+				img.Set(x, y, c)
+			}
+		}
+	}
+}
+
+// drawLogo replaces the head with a distro logo depending on face number, so that each person keeps their logo
+func drawLogo(img *image.RGBA, face image.Rectangle, num int, params map[string]string) {
+	// last logo is the smiley, kept for the emoji renderer
+	if len(logos) < 2 {
+		drawSmiley(img, face, num, params)
+		return
+	}
+	drawImageOnFace(img, face, logos[num%(len(logos)-1)])
+}
+
+// drawSmiley replaces the head with a smiley
+func drawSmiley(img *image.RGBA, face image.Rectangle, num int, params map[string]string) {
+	if len(logos) == 0 {
+		return
+	}
+	drawImageOnFace(img, face, logos[len(logos)-1])
+}
+
+// drawImageOnFace resizes logo to the face height and draws it centered on the face
+func drawImageOnFace(img *image.RGBA, face image.Rectangle, logo image.Image) {
+	logo = resize.Resize(0, uint(face.Dy()), logo, resize.NearestNeighbor)
+	logorect := image.Rect(face.Min.X+face.Dx()/2-logo.Bounds().Dx()/2,
+		face.Min.Y+face.Dy()/2-logo.Bounds().Dy()/2,
+		face.Min.X+logo.Bounds().Dx(),
+		face.Min.Y+logo.Bounds().Dy())
+
+	draw.Draw(img, logorect, logo, image.ZP, draw.Over)
+}
+
+// drawBlur blurs the face with a box blur. Parameters: radius in pixels
+func drawBlur(img *image.RGBA, face image.Rectangle, num int, params map[string]string) {
+	radius := intParam(params, "radius", 8)
+	face = face.Intersect(img.Bounds())
+	if face.Empty() {
+		return
+	}
+
+	// separable blur: horizontal then vertical pass, each one reading from a copy
+	for pass := 0; pass < 2; pass++ {
+		src := image.NewRGBA(face)
+		draw.Draw(src, face, img, face.Min, draw.Src)
+		for y := face.Min.Y; y < face.Max.Y; y++ {
+			for x := face.Min.X; x < face.Max.X; x++ {
+				var r, g, b, n int
+				for d := -radius; d <= radius; d++ {
+					p := image.Pt(x+d, y)
+					if pass == 1 {
+						p = image.Pt(x, y+d)
+					}
+					if !p.In(face) {
+						continue
+					}
+					c := src.RGBAAt(p.X, p.Y)
+					r, g, b, n = r+int(c.R), g+int(c.G), b+int(c.B), n+1
+				}
+				img.SetRGBA(x, y, color.RGBA{uint8(r / n), uint8(g / n), uint8(b / n), 255})
+			}
+		}
+	}
+}
+
+// drawPixelated replaces the face with big blocks of its average colors. Parameters: size of blocks in pixels
+func drawPixelated(img *image.RGBA, face image.Rectangle, num int, params map[string]string) {
+	size := intParam(params, "size", 12)
+	face = face.Intersect(img.Bounds())
+
+	for by := face.Min.Y; by < face.Max.Y; by += size {
+		for bx := face.Min.X; bx < face.Max.X; bx += size {
+			block := image.Rect(bx, by, bx+size, by+size).Intersect(face)
+			var r, g, b, n int
+			for y := block.Min.Y; y < block.Max.Y; y++ {
+				for x := block.Min.X; x < block.Max.X; x++ {
+					c := img.RGBAAt(x, y)
+					r, g, b, n = r+int(c.R), g+int(c.G), b+int(c.B), n+1
+				}
+			}
+			avg := color.RGBA{uint8(r / n), uint8(g / n), uint8(b / n), 255}
+			draw.Draw(img, block, &image.Uniform{avg}, image.ZP, draw.Src)
+		}
+	}
+}
+
+// drawBox draws a bounding box with a label above it. Parameters: color (#rrggbb), label (face track id by default)
+func drawBox(img *image.RGBA, face image.Rectangle, num int, params map[string]string) {
+	c := colorParam(params, "color", color.RGBA{0, 255, 0, 255})
+	label := params["label"]
+	if label == "" {
+		label = fmt.Sprintf("face %d", num)
+	}
+
+	uniform := &image.Uniform{c}
+	for _, edge := range []image.Rectangle{
+		image.Rect(face.Min.X, face.Min.Y, face.Max.X, face.Min.Y+2),
+		image.Rect(face.Min.X, face.Max.Y-2, face.Max.X, face.Max.Y),
+		image.Rect(face.Min.X, face.Min.Y, face.Min.X+2, face.Max.Y),
+		image.Rect(face.Max.X-2, face.Min.Y, face.Max.X, face.Max.Y),
+	} {
+		draw.Draw(img, edge, uniform, image.ZP, draw.Src)
+	}
+
+	d := &font.Drawer{
+		Dst:  img,
+		Src:  uniform,
+		Face: basicfont.Face7x13,
+		Dot:  fixed.P(face.Min.X, face.Min.Y-4),
+	}
+	d.DrawString(label)
+}
+
+// drawFilled hides the face behind a plain rectangle. Parameters: color (#rrggbb)
+func drawFilled(img *image.RGBA, face image.Rectangle, num int, params map[string]string) {
+	c := colorParam(params, "color", color.RGBA{0, 0, 0, 255})
+	draw.Draw(img, face, &image.Uniform{c}, image.ZP, draw.Src)
+}
+
+// intParam returns the positive integer parameter key, or def if it's not set or invalid
+func intParam(params map[string]string, key string, def int) int {
+	v, ok := params[key]
+	if !ok {
+		return def
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil || i <= 0 {
+		fmt.Printf("Invalid renderer parameter %s: %q, using %d\n", key, v, def)
+		return def
+	}
+	return i
+}
+
+// colorParam returns the #rrggbb color parameter key, or def if it's not set or invalid
+func colorParam(params map[string]string, key string, def color.RGBA) color.RGBA {
+	v, ok := params[key]
+	if !ok {
+		return def
+	}
+	var r, g, b uint8
+	if _, err := fmt.Sscanf(v, "#%02x%02x%02x", &r, &g, &b); err != nil {
+		fmt.Printf("Invalid renderer parameter %s: %q, using default color\n", key, v)
+		return def
+	}
+	return color.RGBA{r, g, b, 255}
+}
@@ -0,0 +1,90 @@
+package detection
+
+import (
+	"image"
+	"image/color"
+	"time"
+
+	"github.com/nfnt/resize"
+	"github.com/ubuntu/face-detection-demo/datastore"
+)
+
+// motion is compared on a small grayscale thumbnail of each frame
+const (
+	motionWidth  = 64
+	motionHeight = 48
+)
+
+// frameSampler decides which grabbed frames are processed depending on current sampling policy
+type frameSampler struct {
+	lastProcessed time.Time
+	framesSkipped int
+	reference     []uint8
+}
+
+// nextDue returns when next frame should be processed for time based policies
+func (f *frameSampler) nextDue(s datastore.Sampling) time.Time {
+	return f.lastProcessed.Add(s.Period())
+}
+
+// wantFrame is called for each grabbed frame and tells if it needs to be retrieved for processing
+func (f *frameSampler) wantFrame(s datastore.Sampling) bool {
+	if s.Policy == datastore.FRAMESSAMPLING {
+		f.framesSkipped++
+		return f.framesSkipped >= s.FrameStep
+	}
+	return !time.Now().Before(f.nextDue(s))
+}
+
+// sceneChanged compares the frame to the last one which triggered a detection, returning the motion level between
+// them. The first frame is always considered as a change.
+func (f *frameSampler) sceneChanged(img image.Image, threshold float64) (bool, float64) {
+	thumbnail := grayThumbnail(img)
+	level := motionLevel(f.reference, thumbnail)
+	if f.reference != nil && level < threshold {
+		// still count it as processed to keep the interval or frame step between checks
+		f.processed()
+		return false, level
+	}
+	f.reference = thumbnail
+	return true, level
+}
+
+// processed resets counters after a frame was handled
+func (f *frameSampler) processed() {
+	f.lastProcessed = time.Now()
+	f.framesSkipped = 0
+}
+
+// grayThumbnail downscales img and returns its grayscale pixels
+func grayThumbnail(img image.Image) []uint8 {
+	return grayPixels(resize.Resize(motionWidth, motionHeight, img, resize.Bilinear))
+}
+
+// grayPixels returns img pixels in grayscale, row after row
+func grayPixels(img image.Image) []uint8 {
+	b := img.Bounds()
+	pixels := make([]uint8, 0, b.Dx()*b.Dy())
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			pixels = append(pixels, color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y)
+		}
+	}
+	return pixels
+}
+
+// motionLevel is the mean absolute difference between two thumbnails, between 0 and 1
+func motionLevel(a []uint8, b []uint8) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 1
+	}
+	var diff int
+	for i := range a {
+		d := int(a[i]) - int(b[i])
+		if d < 0 {
+			d = -d
+		}
+		diff += d
+	}
+	return float64(diff) / float64(len(a)*255)
+}
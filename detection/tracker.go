@@ -0,0 +1,135 @@
+package detection
+
+import (
+	"image"
+	"math"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/ubuntu/face-detection-demo/metrics"
+)
+
+const (
+	// tracks without any matching face for more processed frames than this are dropped
+	trackMaxMissed = 3
+	// minimum overlap between a face and a track to associate them
+	trackMinIoU = 0.3
+	// without enough overlap, maximum distance between a face and a track centers to associate them, relative to
+	// the face width
+	trackMaxDistance = 0.5
+)
+
+// track is a face followed across processed frames
+type track struct {
+	id        int64
+	face      image.Rectangle
+	firstSeen time.Time
+	lastSeen  time.Time
+	missed    int
+}
+
+// tracker associates faces detected on successive frames to tracks with stable ids
+type tracker struct {
+	tracks []*track
+}
+
+// lastTrackID is the id of the last created track. It's shared by trackers of all cameras so that ids are unique
+var lastTrackID int64
+
+// match is a possible association between a face and a track, the higher score the better
+type match struct {
+	face, track int
+	score       float64
+}
+
+// newTracker creates a tracker whose track ids start after lastID, unless other trackers already went further
+func newTracker(lastID int64) *tracker {
+	for {
+		current := atomic.LoadInt64(&lastTrackID)
+		if current >= lastID || atomic.CompareAndSwapInt64(&lastTrackID, current, lastID) {
+			break
+		}
+	}
+	return &tracker{}
+}
+
+// update associates faces detected at time now to current tracks, creating new tracks for unmatched faces and
+// dropping lost ones. Return the track of each face, in the same order, and tracks which were dropped.
+func (t *tracker) update(faces []image.Rectangle, now time.Time) (result []*track, ended []*track) {
+	// overlapping pairs first, then close enough ones. Each face and track are matched at most once
+	var matches []match
+	for i, face := range faces {
+		for j, tr := range t.tracks {
+			if o := iou(face, tr.face); o >= trackMinIoU {
+				matches = append(matches, match{i, j, 1 + o})
+			} else if d := centerDistance(face, tr.face) / float64(face.Dx()); d <= trackMaxDistance {
+				matches = append(matches, match{i, j, 1 - d})
+			}
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+
+	result = make([]*track, len(faces))
+	matched := make([]bool, len(t.tracks))
+	for _, m := range matches {
+		if result[m.face] != nil || matched[m.track] {
+			continue
+		}
+		tr := t.tracks[m.track]
+		tr.face, tr.lastSeen, tr.missed = faces[m.face], now, 0
+		result[m.face] = tr
+		matched[m.track] = true
+	}
+
+	// keep tracks seen recently enough, even if not on this frame
+	var tracks []*track
+	for j, tr := range t.tracks {
+		if !matched[j] {
+			tr.missed++
+		}
+		if tr.missed <= trackMaxMissed {
+			tracks = append(tracks, tr)
+		} else {
+			ended = append(ended, tr)
+		}
+	}
+
+	for i, face := range faces {
+		if result[i] != nil {
+			continue
+		}
+		result[i] = &track{id: atomic.AddInt64(&lastTrackID, 1), face: face, firstSeen: now, lastSeen: now}
+		tracks = append(tracks, result[i])
+		metrics.Visitors.Inc()
+	}
+
+	t.tracks = tracks
+	return result, ended
+}
+
+// keep considers faces seen on the last processed frame to be still in sight at time now, the scene being unchanged.
+// Return their tracks
+func (t *tracker) keep(now time.Time) []*track {
+	var result []*track
+	for _, tr := range t.tracks {
+		if tr.missed == 0 {
+			tr.lastSeen = now
+			result = append(result, tr)
+		}
+	}
+	return result
+}
+
+// end drops all current tracks and returns them
+func (t *tracker) end() []*track {
+	ended := t.tracks
+	t.tracks = nil
+	return ended
+}
+
+func centerDistance(a, b image.Rectangle) float64 {
+	dx := float64(a.Min.X+a.Max.X-b.Min.X-b.Max.X) / 2
+	dy := float64(a.Min.Y+a.Max.Y-b.Min.Y-b.Max.Y) / 2
+	return math.Hypot(dx, dy)
+}
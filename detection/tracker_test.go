@@ -0,0 +1,197 @@
+package detection
+
+import (
+	"image"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTrackerUpdate(t *testing.T) {
+	a := image.Rect(0, 0, 100, 100)
+	b := image.Rect(300, 0, 400, 100)
+	// overlapping enough with a
+	aMoved := image.Rect(20, 10, 120, 110)
+	// not overlapping enough with a, but close enough
+	aMovedFast := image.Rect(35, 35, 135, 135)
+	// too far from a
+	aTooFar := image.Rect(60, 60, 160, 160)
+
+	// tracks are referred to by labels: faces with the same label should have the same track, and different labels
+	// different tracks
+	tests := []struct {
+		name string
+		// faces detected on each processed frame
+		frames [][]image.Rectangle
+
+		// track label of each face, and labels of ended tracks, for each frame
+		wantTracks [][]string
+		wantEnded  [][]string
+	}{
+		{"new faces get new tracks",
+			[][]image.Rectangle{{a, b}},
+			[][]string{{"a", "b"}}, [][]string{nil}},
+		{"same face keeps its track",
+			[][]image.Rectangle{{a}, {a}, {a}},
+			[][]string{{"a"}, {"a"}, {"a"}}, [][]string{nil, nil, nil}},
+		{"overlapping face keeps its track",
+			[][]image.Rectangle{{a}, {aMoved}},
+			[][]string{{"a"}, {"a"}}, [][]string{nil, nil}},
+		{"close face keeps its track",
+			[][]image.Rectangle{{a}, {aMovedFast}},
+			[][]string{{"a"}, {"a"}}, [][]string{nil, nil}},
+		{"far face gets a new track",
+			[][]image.Rectangle{{a}, {aTooFar}},
+			[][]string{{"a"}, {"far"}}, [][]string{nil, nil}},
+		{"faces order doesn't matter",
+			[][]image.Rectangle{{a, b}, {b, aMoved}},
+			[][]string{{"a", "b"}, {"b", "a"}}, [][]string{nil, nil}},
+		{"a track is matched only once",
+			[][]image.Rectangle{{a}, {a, aMoved}},
+			[][]string{{"a"}, {"a", "new"}}, [][]string{nil, nil}},
+		{"track missing for a few frames is kept",
+			[][]image.Rectangle{{a}, nil, nil, nil, {a}},
+			[][]string{{"a"}, nil, nil, nil, {"a"}}, [][]string{nil, nil, nil, nil, nil}},
+		{"track missing for too many frames ends",
+			[][]image.Rectangle{{a, b}, {b}, {b}, {b}, {b}, {a}},
+			[][]string{{"a", "b"}, {"b"}, {"b"}, {"b"}, {"b"}, {"back"}},
+			[][]string{nil, nil, nil, nil, {"a"}, nil}},
+		{"tracks of all faces end",
+			[][]image.Rectangle{{a, b}, nil, nil, nil, nil},
+			[][]string{{"a", "b"}, nil, nil, nil, nil},
+			[][]string{nil, nil, nil, nil, {"a", "b"}}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := newTracker(0)
+			start := time.Now()
+			ids := make(map[string]int64)
+			firstSeen := make(map[string]time.Time)
+
+			for n, faces := range tc.frames {
+				now := start.Add(time.Duration(n) * time.Second)
+				tracks, ended := tr.update(faces, now)
+
+				if len(tracks) != len(tc.wantTracks[n]) {
+					t.Fatalf("frame %d: got %d tracks, want %d", n, len(tracks), len(tc.wantTracks[n]))
+				}
+				for i, track := range tracks {
+					label := tc.wantTracks[n][i]
+					id, known := ids[label]
+					if !known {
+						for other, otherID := range ids {
+							if track.id == otherID {
+								t.Fatalf("frame %d: face %d has track of %q, want a new one", n, i, other)
+							}
+						}
+						ids[label], firstSeen[label] = track.id, now
+					} else if track.id != id {
+						t.Errorf("frame %d: face %d has track %d, want track %d of %q", n, i, track.id, id, label)
+					}
+					if track.face != faces[i] {
+						t.Errorf("frame %d: track %q is at %v, want %v", n, label, track.face, faces[i])
+					}
+					if !track.firstSeen.Equal(firstSeen[label]) || !track.lastSeen.Equal(now) {
+						t.Errorf("frame %d: track %q seen from %v to %v, want from %v to %v",
+							n, label, track.firstSeen, track.lastSeen, firstSeen[label], now)
+					}
+				}
+
+				var got, want []int64
+				for _, track := range ended {
+					got = append(got, track.id)
+				}
+				for _, label := range tc.wantEnded[n] {
+					want = append(want, ids[label])
+				}
+				if !equalIDs(got, want) {
+					t.Errorf("frame %d: got ended tracks %v, want %v", n, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestNewTracker(t *testing.T) {
+	current := atomic.LoadInt64(&lastTrackID)
+
+	tests := []struct {
+		name   string
+		lastID int64
+
+		wantID int64
+	}{
+		{"ids start after last saved id", current + 100, current + 101},
+		{"ids never go back", 0, current + 102},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tracks, _ := newTracker(tc.lastID).update([]image.Rectangle{image.Rect(0, 0, 10, 10)}, time.Now())
+			if tracks[0].id != tc.wantID {
+				t.Errorf("got track id %d, want %d", tracks[0].id, tc.wantID)
+			}
+		})
+	}
+}
+
+func TestTrackerKeepAndEnd(t *testing.T) {
+	a := image.Rect(0, 0, 100, 100)
+	b := image.Rect(300, 0, 400, 100)
+
+	tests := []struct {
+		name string
+		// faces of the last two processed frames
+		previous, last []image.Rectangle
+
+		wantKept  int
+		wantEnded int
+	}{
+		{"no track", nil, nil, 0, 0},
+		{"faces of last frame are kept", []image.Rectangle{a}, []image.Rectangle{a, b}, 2, 2},
+		{"missing faces aren't kept, but are still tracked", []image.Rectangle{a, b}, []image.Rectangle{b}, 1, 2},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := newTracker(0)
+			start := time.Now()
+			tr.update(tc.previous, start)
+			tr.update(tc.last, start.Add(time.Second))
+
+			now := start.Add(2 * time.Second)
+			kept := tr.keep(now)
+			if len(kept) != tc.wantKept {
+				t.Errorf("got %d kept tracks, want %d", len(kept), tc.wantKept)
+			}
+			for _, track := range kept {
+				if !track.lastSeen.Equal(now) {
+					t.Errorf("kept track %d last seen at %v, want %v", track.id, track.lastSeen, now)
+				}
+			}
+
+			if ended := tr.end(); len(ended) != tc.wantEnded {
+				t.Errorf("got %d ended tracks, want %d", len(ended), tc.wantEnded)
+			}
+			if ended := tr.end(); len(ended) != 0 {
+				t.Errorf("got %d ended tracks once ended, want none", len(ended))
+			}
+		})
+	}
+}
+
+func equalIDs(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	sort.Slice(a, func(i, j int) bool { return a[i] < a[j] })
+	sort.Slice(b, func(i, j int) bool { return b[i] < b[j] })
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
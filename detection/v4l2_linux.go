@@ -0,0 +1,173 @@
+package detection
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/ubuntu/face-detection-demo/appstate"
+)
+
+// v4l2 ioctls and constants, from linux/videodev2.h
+const (
+	vidiocQueryCap           = 0x80685600
+	vidiocEnumFmt            = 0xc0405602
+	vidiocEnumFrameSizes     = 0xc02c564a
+	vidiocEnumFrameIntervals = 0xc034564b
+
+	v4l2BufTypeVideoCapture = 1
+	v4l2CapVideoCapture     = 0x00000001
+	v4l2CapDeviceCaps       = 0x80000000
+	v4l2FrmTypeDiscrete     = 1
+)
+
+type v4l2Capability struct {
+	driver       [16]byte
+	card         [32]byte
+	busInfo      [32]byte
+	version      uint32
+	capabilities uint32
+	deviceCaps   uint32
+	reserved     [3]uint32
+}
+
+type v4l2FmtDesc struct {
+	index       uint32
+	typ         uint32
+	flags       uint32
+	description [32]byte
+	pixelFormat uint32
+	mbusCode    uint32
+	reserved    [3]uint32
+}
+
+// v4l2FrmSizeEnum holds either a discrete size (width, height) or stepwise ones (min width, max width, step width,
+// min height, max height, step height)
+type v4l2FrmSizeEnum struct {
+	index       uint32
+	pixelFormat uint32
+	typ         uint32
+	size        [6]uint32
+	reserved    [2]uint32
+}
+
+// v4l2FrmIvalEnum holds either a discrete interval (numerator, denominator) or stepwise ones (min, max, step)
+type v4l2FrmIvalEnum struct {
+	index       uint32
+	pixelFormat uint32
+	width       uint32
+	height      uint32
+	typ         uint32
+	interval    [6]uint32
+	reserved    [2]uint32
+}
+
+// describeCamera queries name, resolutions and frame rates of a camera from its video device. Modes of all pixel
+// formats are merged
+func describeCamera(i int) appstate.CameraInfo {
+	info := appstate.CameraInfo{Camera: i + 1, Name: fmt.Sprintf("Camera %d", i+1)}
+
+	f, err := os.Open(fmt.Sprintf("%s%d", strings.TrimSuffix(videoDevicesGlob, "*"), i))
+	if err != nil {
+		return info
+	}
+	defer f.Close()
+	fd := f.Fd()
+
+	var c v4l2Capability
+	if ioctl(fd, vidiocQueryCap, unsafe.Pointer(&c)) != nil {
+		return info
+	}
+	if name := string(bytes.TrimRight(c.card[:], "\x00")); name != "" {
+		info.Name = name
+	}
+	caps := c.capabilities
+	if caps&v4l2CapDeviceCaps != 0 {
+		caps = c.deviceCaps
+	}
+	if caps&v4l2CapVideoCapture == 0 {
+		return info
+	}
+
+	modes := make(map[[2]int]map[float64]bool)
+	for fmtIndex := uint32(0); ; fmtIndex++ {
+		desc := v4l2FmtDesc{index: fmtIndex, typ: v4l2BufTypeVideoCapture}
+		if ioctl(fd, vidiocEnumFmt, unsafe.Pointer(&desc)) != nil {
+			break
+		}
+		for sizeIndex := uint32(0); ; sizeIndex++ {
+			size := v4l2FrmSizeEnum{index: sizeIndex, pixelFormat: desc.pixelFormat}
+			if ioctl(fd, vidiocEnumFrameSizes, unsafe.Pointer(&size)) != nil {
+				break
+			}
+			// only report bounds of stepwise sizes
+			sizes := [][2]uint32{{size.size[0], size.size[1]}}
+			if size.typ != v4l2FrmTypeDiscrete {
+				sizes = [][2]uint32{{size.size[0], size.size[3]}, {size.size[1], size.size[4]}}
+			}
+			for _, s := range sizes {
+				key := [2]int{int(s[0]), int(s[1])}
+				if modes[key] == nil {
+					modes[key] = make(map[float64]bool)
+				}
+				for _, fps := range frameRates(fd, desc.pixelFormat, s[0], s[1]) {
+					modes[key][fps] = true
+				}
+			}
+			if size.typ != v4l2FrmTypeDiscrete {
+				break
+			}
+		}
+	}
+
+	for size, rates := range modes {
+		mode := appstate.CameraMode{Width: size[0], Height: size[1]}
+		for fps := range rates {
+			mode.FPS = append(mode.FPS, fps)
+		}
+		sort.Float64s(mode.FPS)
+		info.Modes = append(info.Modes, mode)
+	}
+	sort.Slice(info.Modes, func(i, j int) bool {
+		a, b := info.Modes[i], info.Modes[j]
+		return a.Width < b.Width || (a.Width == b.Width && a.Height < b.Height)
+	})
+	return info
+}
+
+// frameRates returns frame rates supported for a pixel format and resolution. Only bounds of stepwise intervals are
+// reported
+func frameRates(fd uintptr, pixelFormat, width, height uint32) []float64 {
+	var rates []float64
+	for i := uint32(0); ; i++ {
+		ival := v4l2FrmIvalEnum{index: i, pixelFormat: pixelFormat, width: width, height: height}
+		if ioctl(fd, vidiocEnumFrameIntervals, unsafe.Pointer(&ival)) != nil {
+			break
+		}
+		// intervals are fractions of seconds: rates are their inverse
+		fractions := [][2]uint32{{ival.interval[0], ival.interval[1]}}
+		if ival.typ != v4l2FrmTypeDiscrete {
+			fractions = [][2]uint32{{ival.interval[0], ival.interval[1]}, {ival.interval[2], ival.interval[3]}}
+		}
+		for _, f := range fractions {
+			if f[0] != 0 {
+				rates = append(rates, float64(f[1])/float64(f[0]))
+			}
+		}
+		if ival.typ != v4l2FrmTypeDiscrete {
+			break
+		}
+	}
+	return rates
+}
+
+func ioctl(fd uintptr, request uintptr, arg unsafe.Pointer) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, request, uintptr(arg)); errno != 0 {
+		return errno
+	}
+	return nil
+}
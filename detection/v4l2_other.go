@@ -0,0 +1,15 @@
+//go:build !linux
+// +build !linux
+
+package detection
+
+import (
+	"fmt"
+
+	"github.com/ubuntu/face-detection-demo/appstate"
+)
+
+// describeCamera can only name cameras without video4linux
+func describeCamera(i int) appstate.CameraInfo {
+	return appstate.CameraInfo{Camera: i + 1, Name: fmt.Sprintf("Camera %d", i+1)}
+}
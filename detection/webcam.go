@@ -2,198 +2,464 @@ package detection
 
 import (
 	"fmt"
-	"path"
-	"sync"
+	"image"
+	"strconv"
 	"time"
 
-	"github.com/lazywei/go-opencv/opencv"
 	"github.com/ubuntu/face-detection-demo/appstate"
 	"github.com/ubuntu/face-detection-demo/comm"
 	"github.com/ubuntu/face-detection-demo/datastore"
 	"github.com/ubuntu/face-detection-demo/messages"
+	"github.com/ubuntu/face-detection-demo/metrics"
 )
 
-var (
-	stop chan interface{}
-
-	cameraOn   bool
-	currentCam = -1
-)
+// cameraDetection is face detection running on one frame source in its own goroutine
+type cameraDetection struct {
+	ctrl *Controller
+	// camera number, -1 for other frame sources
+	camera int
+	stop   chan interface{}
+	// done is closed once the goroutine ended
+	done chan interface{}
+	// running is true once the frame source is opened
+	running bool
+	// failure is why the camera isn't running, while waiting for it to be opened
+	failure string
+	// source is the opened frame source, interrupted when asked to stop
+	source FrameSource
+}
 
 func init() {
 	DetectCameras()
 }
 
-// StartCameraDetect creates a go routine handling web cam recording and image generation
-func StartCameraDetect(rootdir string, shutdown <-chan interface{}, wg *sync.WaitGroup) {
-	if cameraOn {
-		fmt.Println("Detection command received but already started")
-		return
-	}
-	stop = make(chan interface{})
+// startDetection creates a go routine handling recording and image generation on camera. Only a single camera can
+// fallback to camera 0 if it can't be opened. Cameras which can't be opened are waited for until detection is
+// stopped. ready receives once nil when detection runs, errWaitingCamera or why it failed. Called with mutex held
+func (c *Controller) startDetection(camera int, single bool, ready chan<- error) {
+	d := &cameraDetection{ctrl: c, camera: camera, stop: make(chan interface{}), done: make(chan interface{})}
+	c.detections[camera] = d
 
 	// send the main quit channel to stop if we got a shutdown request
 	// we can stop in two ways, hence the use of this channel
 	go func() {
 		select {
-		case <-shutdown:
-			close(stop)
-		case <-stop:
+		case <-c.shutdown:
+			c.mutex.Lock()
+			d.end()
+			c.mutex.Unlock()
+		case <-d.stop:
 		}
 	}()
 
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		defer func() { cameraOn = false }()
-		defer fmt.Println("Stop camera")
-
-		cap := openCamera(datastore.Camera())
-		if cap == nil {
-			panic(fmt.Sprintf("Cannot open camera %d", currentCam))
+	// only the first outcome is reported to ready, later failures being reported when the goroutine ends
+	var failure error
+	report := func(err error) {
+		if ready == nil {
+			failure = err
+			return
 		}
-		defer cap.Release()
-		cameraOn = true
-		datastore.SetFaceDetection(true)
-		comm.WSserv.SendAllClients(&messages.WSMessage{
-			Type:          "facedetection",
-			FaceDetection: datastore.FaceDetection(),
-		})
+		ready <- err
+		ready = nil
+	}
 
-		detectFace(cap, rootdir, stop)
-	}()
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		defer close(d.done)
+		defer c.UpdateCameraState()
+		defer func() { c.detectionEnded(camera, d, failure) }()
+		defer fmt.Println("Stop camera", camera)
 
-}
+		source, err := d.openSource(single)
+		if err != nil && d.camera >= 0 {
+			// wait for the camera to be plugged (or any other one for a single camera), detection starting then
+			report(errWaitingCamera)
+			if source = d.reopen(single); source == nil {
+				return
+			}
+		} else if err != nil {
+			fmt.Println("Cannot open frame source, detection not started")
+			report(err)
+			return
+		}
+		d.setSource(source)
+		defer func() {
+			if source != nil {
+				source.Release()
+			}
+		}()
+		detector, err := NewDetector(datastore.DetectorBackend(), c.rootdir)
+		if err != nil {
+			fmt.Println("Cannot load face detector, detection not started:", err)
+			report(fmt.Errorf("can't load face detector: %s", err))
+			return
+		}
+		defer detector.Release()
+		d.setRunning(true)
+		report(nil)
+		c.detectionRunning()
 
-// fallback to camera 0 if can't open requested camera number
-func openCamera(cameraNum int) *opencv.Capture {
-	currentCam = cameraNum
-	cap := opencv.NewCameraCapture(currentCam)
-	if cap == nil && currentCam != 0 {
-		fmt.Printf("Can't open camera %d. Trying fallback to camera 0\n", currentCam)
-		currentCam = 0
-		cap = opencv.NewCameraCapture(currentCam)
-		if cap != nil {
-			datastore.SetCamera(currentCam)
+		for d.detectFace(source, detector) {
+			// camera unplugged: reopen it, or fail over to another one, until detection is stopped
+			fmt.Printf("Camera %d lost\n", d.camera)
+			metrics.CameraDisconnects.Inc()
+			d.setFailure(fmt.Sprintf("camera %d lost", d.camera+1))
 			comm.WSserv.SendAllClients(&messages.WSMessage{
-				Type: "newcameraactivated",
+				Type: "cameralost",
 				// camera is offsetted by 1 for the client
-				Camera: currentCam + 1})
+				Camera: d.camera + 1})
+			source.Release()
+			if source = d.reopen(single); source == nil {
+				return
+			}
+			d.setSource(source)
+			d.setRunning(true)
+			comm.WSserv.SendAllClients(&messages.WSMessage{
+				Type:   "camerareconnected",
+				Camera: d.camera + 1})
 		}
-	}
-	return cap
+	}()
 }
 
-// EndCameraDetect stop the associated goroutine turning on camera
-func EndCameraDetect() {
-	datastore.SetFaceDetection(false)
+// enableFaceDetection saves detection as enabled and notifies clients if it wasn't
+func enableFaceDetection() {
+	if datastore.FaceDetection() {
+		return
+	}
+	datastore.SetFaceDetection(true)
 	comm.WSserv.SendAllClients(&messages.WSMessage{
 		Type:          "facedetection",
 		FaceDetection: datastore.FaceDetection(),
 	})
-	if !cameraOn {
-		fmt.Println("Turning off detection command received but not started")
-		return
+}
+
+func (d *cameraDetection) setRunning(running bool) {
+	d.ctrl.mutex.Lock()
+	d.running = running
+	d.failure = ""
+	d.ctrl.mutex.Unlock()
+	d.ctrl.UpdateCameraState()
+}
+
+// setFailure records why the camera isn't running while waiting for it
+func (d *cameraDetection) setFailure(failure string) {
+	d.ctrl.mutex.Lock()
+	d.running = false
+	d.failure = failure
+	d.ctrl.mutex.Unlock()
+	d.ctrl.UpdateCameraState()
+}
+
+// UpdateCameraState computes the status of cameras and sends it to clients if it changed
+func (c *Controller) UpdateCameraState() {
+	if appstate.SetCameraState(c.cameraState()) {
+		state := appstate.CurrentCameraState()
+		fmt.Println("Camera state changed to", state.Status, state.Error)
+		comm.WSserv.SendAllClients(&messages.WSMessage{
+			Type:        "camerastate",
+			CameraState: &state})
 	}
-	close(stop)
 }
 
-// RestartCamera stops and restarts the camera in a sync fashion (wait for the camera to stop before sending the Start signal)
-func RestartCamera(rootdir string, shutdown <-chan interface{}, wg *sync.WaitGroup) {
-	if !cameraOn {
-		// check again after a second in case of a start + restart is issued.
-		// FIXME: this should be way better handled
-		time.Sleep(time.Second * 1)
-		if !cameraOn {
-			StartCameraDetect(rootdir, shutdown, wg)
-			return
+// cameraState returns the status of cameras from available ones and detections waiting for their camera
+func (c *Controller) cameraState() appstate.CameraState {
+	state := availableCamerasState()
+	if state.Status != appstate.CAMERAOK || !kindIsCamera() {
+		return state
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	var failed *cameraDetection
+	for _, d := range c.detections {
+		if d.failure != "" && (failed == nil || d.camera < failed.camera) {
+			failed = d
 		}
 	}
-	close(stop)
-	for {
-		if !cameraOn {
-			break
+	if failed == nil {
+		return state
+	}
+	// camera is offsetted by 1 for the client
+	return appstate.CameraState{Status: appstate.CAMERAFAILED, Camera: failed.camera + 1, Error: failed.failure}
+}
+
+// availableCamerasState returns the status of cameras, only considering if any is available when needed
+func availableCamerasState() appstate.CameraState {
+	if kindIsCamera() && len(appstate.AvailableCameras()) == 0 {
+		return appstate.CameraState{Status: appstate.NOCAMERA, Error: "no camera detected"}
+	}
+	return appstate.CameraState{Status: appstate.CAMERAOK}
+}
+
+// end asks detection to stop, if not already requested, interrupting any blocked grab. Called with mutex held
+func (d *cameraDetection) end() {
+	select {
+	case <-d.stop:
+	default:
+		close(d.stop)
+		if i, ok := d.source.(interrupter); ok {
+			i.Interrupt()
+		}
+	}
+}
+
+// setCamera changes the camera detection runs on, once failed over to another one
+func (d *cameraDetection) setCamera(camera int) {
+	d.ctrl.mutex.Lock()
+	defer d.ctrl.mutex.Unlock()
+	d.camera = camera
+}
+
+// setSource records the opened frame source, interrupting it right away if detection is already stopping
+func (d *cameraDetection) setSource(source FrameSource) {
+	d.ctrl.mutex.Lock()
+	defer d.ctrl.mutex.Unlock()
+	d.source = source
+	select {
+	case <-d.stop:
+		if i, ok := source.(interrupter); ok {
+			i.Interrupt()
+		}
+	default:
+	}
+}
+
+// open frame source set in settings
+func (d *cameraDetection) openSource(fallback bool) (FrameSource, error) {
+	if d.camera < 0 {
+		kind, sourcepath := datastore.FrameSource()
+		source, err := NewFrameSource(kind, sourcepath, -1)
+		if err != nil {
+			fmt.Println("Can't open frame source:", err)
+			return nil, err
 		}
-		time.Sleep(1 * time.Second)
+		return source, nil
+	}
+	return d.openCamera(fallback)
+}
+
+// fallback to camera 0 if can't open requested camera number and fallback is true
+func (d *cameraDetection) openCamera(fallback bool) (FrameSource, error) {
+	source, err := newCameraSource(d.camera)
+	if err != nil {
+		metrics.CameraOpenFailures.Inc()
+	}
+	if err != nil && fallback && d.camera != 0 {
+		fmt.Printf("Can't open camera %d. Trying fallback to camera 0\n", d.camera)
+		d.setCamera(0)
+		source, err = newCameraSource(d.camera)
+		if err != nil {
+			metrics.CameraOpenFailures.Inc()
+		} else {
+			datastore.SetCamera(d.camera)
+			comm.WSserv.SendAllClients(&messages.WSMessage{
+				Type: "newcameraactivated",
+				// camera is offsetted by 1 for the client
+				Camera:        d.camera + 1,
+				ActiveCameras: messages.ClientCameras(datastore.Cameras())})
+		}
+	}
+	if err != nil {
+		fmt.Println(err)
+		return nil, err
 	}
-	StartCameraDetect(rootdir, shutdown, wg)
+	return source, nil
 }
 
-// DetectCameras detects and files the index of available cameras. Take into account current camera if already on
+// DetectCameras detects and files the index of available cameras
 func DetectCameras() {
-	appstate.AvailableCameras = make([]int, 0)
+	cameras := probeCameras(nil)
+	appstate.SetAvailableCameras(cameras, describeCameras(cameras))
 
+	// the service still starts without any camera: detection will wait for one to be plugged
+	appstate.SetCameraState(availableCamerasState())
+	if len(cameras) == 0 && kindIsCamera() {
+		fmt.Println("No camera detected. Waiting for one to be plugged")
+	}
+}
+
+// probeCameras returns the index of cameras which can be opened or are already on, offsetted by 1 for the client
+func probeCameras(running []int) []int {
+	cameras := make([]int, 0)
+
+	inuse := make(map[int]bool)
+	if kindIsCamera() {
+		for _, c := range running {
+			inuse[c] = true
+		}
+	}
 	for i := 0; i < 10; i++ {
-		cap := opencv.NewCameraCapture(i)
-		if cap != nil || (cameraOn && i == currentCam) {
-			if cap != nil {
-				cap.Release()
-			}
+		if inuse[i] || cameraAvailable(i) {
 			// camera is offsetted by 1 for the client
-			appstate.AvailableCameras = append(appstate.AvailableCameras, i+1)
+			cameras = append(cameras, i+1)
 		}
 	}
+	return cameras
+}
 
-	if len(appstate.AvailableCameras) == 0 {
-		panic("No camera detected")
+// describeCameras returns name and supported modes of cameras, offsetted by 1 for the client
+func describeCameras(cameras []int) []appstate.CameraInfo {
+	infos := make([]appstate.CameraInfo, 0, len(cameras))
+	for _, c := range cameras {
+		infos = append(infos, describeCamera(c-1))
 	}
+	return infos
+}
+
+func kindIsCamera() bool {
+	kind, _ := datastore.FrameSource()
+	return kind == datastore.CAMERASOURCE
 }
 
-func detectFace(cap *opencv.Capture, rootdir string, stop <-chan interface{}) {
-	nextFrameSec := time.Now()
-	cascade := opencv.LoadHaarClassifierCascade(path.Join(rootdir, "frontfacedetection.xml"))
-	defer cascade.Release()
+// detectFace processes frames of source until detection is stopped. Return true if it ended because the camera was
+// lost
+func (d *cameraDetection) detectFace(source FrameSource, detector Detector) bool {
+	sampler := &frameSampler{}
+	lastFrame := time.Now()
+	retryDelay := grabRetryDelay
+	// track ids are unique over time, even after a restart
+	lastID, err := datastore.DB.LastTrackID()
+	if err != nil {
+		fmt.Println("Couldn't get last track id:", err)
+	}
+	tracker := newTracker(lastID)
+	defer func() { d.endVisits(tracker.end()) }()
 	for {
 
 		select {
-		case <-stop:
+		case <-d.stop:
 			fmt.Println("Stop processing webcam events")
-			return
+			return false
 		default:
 		}
 
-		if cap.GrabFrame() {
+		// settings can be changed live
+		sampling := datastore.FrameSampling()
 
-			// we dropped all grab frames and only take one every X seconds (no support in opencv go binding for CV_CAP_PROP_BUFFERSIZE)
-			// if we didn't grab them one after another, we'll have past frames when proceeding
-			if time.Now().Before(nextFrameSec) {
-				continue
+		// non live sources only provide a frame when asked: wait before grabbing it
+		if !source.Live() {
+			select {
+			case <-d.stop:
+				fmt.Println("Stop processing webcam events")
+				return false
+			case <-time.After(sampler.nextDue(sampling).Sub(time.Now())):
 			}
+		}
 
-			// treat frame
-			img := cap.RetrieveFrame(1)
-			if img != nil {
-				faces := cascade.DetectObjects(img)
-				drawAndSaveFaces(img, faces)
+		if !source.GrabFrame() {
+			// don't spin on exhausted non live sources
+			if !source.Live() {
+				sampler.processed()
+				continue
+			}
+			// cameras stop providing frames once unplugged
+			if d.camera >= 0 && time.Since(lastFrame) > cameraLostTimeout {
+				return true
+			}
+			// nor on live sources which are down
+			select {
+			case <-d.stop:
+				fmt.Println("Stop processing webcam events")
+				return false
+			case <-time.After(retryDelay):
 			}
+			retryDelay *= 2
+			if retryDelay > grabRetryMaxDelay {
+				retryDelay = grabRetryMaxDelay
+			}
+			continue
+		}
+		lastFrame = time.Now()
+		retryDelay = grabRetryDelay
+		metrics.FramesGrabbed.Inc()
 
+		// we drop grabbed frames we don't want to process (no support in opencv go binding for CV_CAP_PROP_BUFFERSIZE)
+		// if we didn't grab them one after another, we'll have past frames when proceeding
+		if source.Live() && !sampler.wantFrame(sampling) {
+			continue
 		}
-		nextFrameSec = time.Now().Add(time.Duration(5 * time.Second))
+
+		// treat frame
+		img := source.RetrieveFrame()
+		if img == nil {
+			continue
+		}
+		// skip detection, the most expensive step, on still scenes: persons of the last processed frame are still there
+		if sampling.MotionGate {
+			changed, level := sampler.sceneChanged(img, sampling.MotionThreshold)
+			metrics.MotionLevel.WithLabelValues(strconv.Itoa(d.camera + 1)).Set(level)
+			if !changed {
+				metrics.FramesWithoutMotion.Inc()
+				d.drawAndSaveFaces(img, tracker.keep(time.Now()))
+				continue
+			}
+		}
+		start := time.Now()
+		faces := detector.Detect(img)
+		metrics.DetectionDuration.Observe(time.Since(start).Seconds())
+		metrics.FramesProcessed.Inc()
+		tracks, ended := tracker.update(faces, time.Now())
+		d.endVisits(ended)
+		d.drawAndSaveFaces(img, tracks)
+		sampler.processed()
 	}
 
 }
 
-func drawAndSaveFaces(img *opencv.IplImage, faces []*opencv.Rect) {
+func (d *cameraDetection) drawAndSaveFaces(img image.Image, tracks []*track) {
 	// save raw image before modifications
 	detectedFace := false
 
-	dest := RenderedImage{RenderingMode: datastore.RenderingMode()}
+	zones := cameraZones(d.camera)
+	dest := RenderedImage{
+		Renderer: datastore.FaceRenderer(),
+		Zones:    zones,
+		Filename: d.filename(appstate.DetectedFilename),
+	}
 
-	for num, face := range faces {
+	detections := make([]datastore.Detection, 0, len(tracks))
+	for _, t := range tracks {
 		fmt.Println("face detected")
 		detectedFace = true
-		dest.DrawFace(face, num, img)
+		// renderers get the track id so that a person is always drawn the same way
+		dest.DrawFace(t.face, int(t.id), img)
+		detections = append(detections, datastore.Detection{
+			X:           t.face.Min.X,
+			Y:           t.face.Min.Y,
+			Width:       t.face.Dx(),
+			Height:      t.face.Dy(),
+			FrameWidth:  img.Bounds().Dx(),
+			FrameHeight: img.Bounds().Dy(),
+			Camera:      d.camera,
+			Renderer:    dest.Renderer.Name,
+			TrackID:     t.id,
+			TrackStart:  t.firstSeen,
+		})
 	}
 
 	// store and save stat
-	np := len(faces)
+	np := len(tracks)
+	metrics.Persons.WithLabelValues(strconv.Itoa(d.camera + 1)).Set(float64(np))
 	if appstate.BrokenMode {
 		np = -np
 	}
-	s := &datastore.Stat{TimeStamp: time.Now(), NumPersons: np}
-	datastore.DB.Add(*s)
+	s := &datastore.Stat{TimeStamp: time.Now(), NumPersons: np, Camera: d.camera,
+		Zones: zoneCounts(zones, tracks, img.Bounds())}
+	for i := range detections {
+		detections[i].TimeStamp = s.TimeStamp
+	}
 
-	if err := saveatomic(datadir, screenshotname, (*opencvImg)(img)); err != nil {
+	// raw frame is never saved in privacy mode: save a copy with anonymized faces instead
+	var capture saver = rawImg{img}
+	if datastore.PrivacyMode().Enabled {
+		frame := newRGBAImg(img)
+		for _, t := range tracks {
+			frame.addFace(t.face)
+		}
+		capture = frame
+	}
+	if err := saveatomic(datadir, d.filename(appstate.ScreenshotFilename), capture); err != nil {
 		fmt.Println(err)
 	}
 
@@ -202,10 +468,37 @@ func drawAndSaveFaces(img *opencv.IplImage, faces []*opencv.Rect) {
 		dest.Save()
 	}
 
-	// send messages to clients
-	comm.WSserv.SendAllClients(&messages.WSMessage{
-		Type:                    "newstat",
-		NewStat:                 s,
-		RefreshScreenshot:       true,
-		RefreshDetectScreenshot: detectedFace})
+	// send messages to clients once saved, so that they get stat and detections ids
+	camera := d.camera
+	datastore.DB.Add(*s, detections, func(s datastore.Stat, detections []datastore.Detection) {
+		comm.WSserv.SendAllClients(&messages.WSMessage{
+			Type:    "newstat",
+			NewStat: &s,
+			// camera is offsetted by 1 for the client, 0 for other frame sources
+			Camera:                  camera + 1,
+			Detections:              detections,
+			RefreshScreenshot:       true,
+			RefreshDetectScreenshot: detectedFace})
+	})
+}
+
+// filename returns the name of a screenshot of this camera. The first active camera and other frame sources keep
+// the historical names
+func (d *cameraDetection) filename(name string) string {
+	if d.camera < 0 || d.camera == datastore.Camera() {
+		return name
+	}
+	return appstate.CameraFilename(name, d.camera)
+}
+
+// endVisits saves visits of tracks which are not in sight anymore
+func (d *cameraDetection) endVisits(tracks []*track) {
+	for _, t := range tracks {
+		v := datastore.Visit{TrackID: t.id, Camera: d.camera, Enter: t.firstSeen, Exit: t.lastSeen}
+		metrics.VisitDuration.Observe(v.Dwell().Seconds())
+		datastore.DB.AddVisit(v)
+		comm.WSserv.SendAllClients(&messages.WSMessage{
+			Type:  "visit",
+			Visit: &v})
+	}
 }
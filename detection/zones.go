@@ -0,0 +1,89 @@
+package detection
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/ubuntu/face-detection-demo/datastore"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+var zoneColor = color.RGBA{255, 200, 0, 255}
+
+// cameraZones returns zones in which persons are counted on frames of camera
+func cameraZones(camera int) []datastore.Zone {
+	var zones []datastore.Zone
+	for _, z := range datastore.Zones() {
+		if z.AppliesTo(camera) {
+			zones = append(zones, z)
+		}
+	}
+	return zones
+}
+
+// zoneCounts returns the number of faces whose center is in each zone of a frame of size bounds
+func zoneCounts(zones []datastore.Zone, tracks []*track, bounds image.Rectangle) map[string]int {
+	if len(zones) == 0 {
+		return nil
+	}
+	counts := make(map[string]int, len(zones))
+	for _, z := range zones {
+		counts[z.Name] = 0
+		for _, t := range tracks {
+			x := float64(t.face.Min.X+t.face.Dx()/2-bounds.Min.X) / float64(bounds.Dx())
+			y := float64(t.face.Min.Y+t.face.Dy()/2-bounds.Min.Y) / float64(bounds.Dy())
+			if z.Contains(x, y) {
+				counts[z.Name]++
+			}
+		}
+	}
+	return counts
+}
+
+// drawZones draws zone outlines with their name
+func drawZones(img *image.RGBA, zones []datastore.Zone) {
+	b := img.Bounds()
+	toPixels := func(p datastore.Point) image.Point {
+		return image.Pt(b.Min.X+int(p.X*float64(b.Dx()-1)), b.Min.Y+int(p.Y*float64(b.Dy()-1)))
+	}
+
+	for _, z := range zones {
+		for i := range z.Points {
+			drawLine(img, toPixels(z.Points[i]), toPixels(z.Points[(i+1)%len(z.Points)]), zoneColor)
+		}
+		d := &font.Drawer{
+			Dst:  img,
+			Src:  &image.Uniform{zoneColor},
+			Face: basicfont.Face7x13,
+			Dot:  fixed.P(toPixels(z.Points[0]).X+4, toPixels(z.Points[0]).Y+14),
+		}
+		d.DrawString(z.Name)
+	}
+}
+
+// drawLine draws a 2 pixels wide line from a to b
+func drawLine(img *image.RGBA, a, b image.Point, c color.RGBA) {
+	dx, dy := abs(b.X-a.X), abs(b.Y-a.Y)
+	steps := dx
+	if dy > steps {
+		steps = dy
+	}
+	uniform := &image.Uniform{c}
+	for i := 0; i <= steps; i++ {
+		p := a
+		if steps > 0 {
+			p = image.Pt(a.X+(b.X-a.X)*i/steps, a.Y+(b.Y-a.Y)*i/steps)
+		}
+		draw.Draw(img, image.Rect(p.X, p.Y, p.X+2, p.Y+2).Intersect(img.Bounds()), uniform, image.ZP, draw.Src)
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ubuntu/face-detection-demo/comm"
+	"github.com/ubuntu/face-detection-demo/messages"
+)
+
+// cameras lists available cameras with their supported modes, or changes capture settings of one of them
+func cameras(args []string) {
+	flags := flag.NewFlagSet("cameras", flag.ExitOnError)
+	resolution := flags.String("resolution", "", "Capture resolution as WIDTHxHEIGHT, or default")
+	fps := flags.Float64("fps", 0, "Capture frame rate (-1 for the camera default)")
+	exposure := flags.String("exposure", "", "Exposure value, depending on the camera driver, or auto")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage of %s cameras:\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s cameras [list]\tlist available cameras, their supported modes and capture settings\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s cameras set N [options]\tchange capture settings of camera N\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+
+	cmd := "list"
+	if len(args) > 0 {
+		cmd = args[0]
+	}
+
+	req := &messages.Request{}
+	switch {
+	case cmd == "list" && len(args) <= 1:
+	case cmd == "set" && len(args) >= 2:
+		camera, err := strconv.Atoi(args[1])
+		if err != nil || camera < 1 {
+			subcommandErrorOut(flags, fmt.Sprintf("invalid camera number: %s", args[1]))
+		}
+		flags.Parse(args[2:])
+		if flags.NArg() > 0 {
+			subcommandErrorOut(flags, "Invalid argument set")
+		}
+
+		params := &messages.CameraParams{Camera: int32(camera), Fps: *fps}
+		if *resolution == "default" {
+			params.Width, params.Height = -1, -1
+		} else if *resolution != "" {
+			var w, h int32
+			if _, err := fmt.Sscanf(*resolution, "%dx%d", &w, &h); err != nil || w < 1 || h < 1 {
+				subcommandErrorOut(flags, fmt.Sprintf("invalid resolution: %s", *resolution))
+			}
+			params.Width, params.Height = w, h
+		}
+		if *exposure == "auto" {
+			params.AutoExposure = true
+		} else if *exposure != "" {
+			if params.Exposure, err = strconv.ParseFloat(*exposure, 64); err != nil {
+				subcommandErrorOut(flags, fmt.Sprintf("invalid exposure: %s", *exposure))
+			}
+		}
+		req.Action = &messages.Action{CameraParams: params}
+	default:
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	resp, err := comm.SendToSocket(req)
+	if err != nil {
+		os.Exit(1)
+	}
+	if !resp.Success {
+		fmt.Println("Error:", resp.Error)
+		os.Exit(1)
+	}
+	if req.Action != nil || resp.Status == nil {
+		return
+	}
+
+	if len(resp.Status.Cameras) == 0 {
+		fmt.Println("No camera available")
+	}
+	for _, c := range resp.Status.Cameras {
+		var modes []string
+		for _, m := range c.Modes {
+			var rates []string
+			for _, r := range m.Fps {
+				rates = append(rates, strconv.FormatFloat(r, 'g', 4, 64))
+			}
+			modes = append(modes, fmt.Sprintf("%dx%d@%s", m.Width, m.Height, strings.Join(rates, "/")))
+		}
+		fmt.Printf("%d: %s\n", c.Camera, c.Name)
+		if len(modes) > 0 {
+			fmt.Println("   modes:   ", strings.Join(modes, " "))
+		}
+		fmt.Println("   settings:", formatCameraParams(c.Params))
+	}
+}
+
+// formatCameraParams describes capture settings, mentioning camera defaults
+func formatCameraParams(p *messages.CameraParams) string {
+	resolution, fps, exposure := "default resolution", "default fps", "auto exposure"
+	if p.Width > 0 {
+		resolution = fmt.Sprintf("%dx%d", p.Width, p.Height)
+	}
+	if p.Fps > 0 {
+		fps = fmt.Sprintf("%g fps", p.Fps)
+	}
+	if p.Exposure != 0 {
+		exposure = fmt.Sprintf("exposure %g", p.Exposure)
+	}
+	return strings.Join([]string{resolution, fps, exposure}, ", ")
+}
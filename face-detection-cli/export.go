@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const defaultServerURL = "http://localhost:8080"
+
+// export streams collected stats from the service web server to stdout or a file
+func export(args []string) {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	format := flags.String("format", "csv", "Export format: csv, json or ndjson")
+	from := flags.String("from", "", "Only export stats since this time (RFC3339, like 2017-01-02T15:04:05Z)")
+	to := flags.String("to", "", "Only export stats until this time (RFC3339)")
+	output := flags.String("output", "", "Write to this file instead of standard output")
+	server := flags.String("server", defaultServerURL, "Url of the face detection service web server")
+	flags.Parse(args)
+	if flags.NArg() > 0 {
+		subcommandErrorOut(flags, "Invalid argument set")
+	}
+
+	params := url.Values{}
+	params.Set("format", *format)
+	if *from != "" {
+		params.Set("from", *from)
+	}
+	if *to != "" {
+		params.Set("to", *to)
+	}
+
+	resp, err := http.Get(strings.TrimSuffix(*server, "/") + "/data/export?" + params.Encode())
+	if err != nil {
+		fmt.Println("Couldn't connect to web server. Is your service running?", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		fmt.Printf("Couldn't export stats: %s", msg)
+		os.Exit(1)
+	}
+
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fmt.Println("Couldn't create output file:", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	if _, err = io.Copy(out, resp.Body); err != nil {
+		fmt.Println("Couldn't export stats:", err)
+		os.Exit(1)
+	}
+}
+
+func subcommandErrorOut(flags *flag.FlagSet, message string) {
+	fmt.Println("Error:", message)
+	flags.PrintDefaults()
+	os.Exit(1)
+}
@@ -4,6 +4,9 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/ubuntu/face-detection-demo/comm"
 	"github.com/ubuntu/face-detection-demo/messages"
@@ -11,13 +14,68 @@ import (
 
 func main() {
 
+	// subcommands have their own set of flags
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "export":
+			export(os.Args[2:])
+			return
+		case "status":
+			status(os.Args[2:])
+			return
+		case "watch":
+			watch(os.Args[2:])
+			return
+		case "zones":
+			zones(os.Args[2:])
+			return
+		case "cameras":
+			cameras(os.Args[2:])
+			return
+		}
+	}
+
+	flag.Usage = usage
+
 	enableCam := flag.Bool("enable-camera", false, "Enable the camera detection service")
 	disableCam := flag.Bool("disable-camera", false, "Disable the camera detection service")
 
 	funMode := flag.Bool("fun", false, "Show some distro logos instead of the head")
 	normalMode := flag.Bool("normal", false, "Show some circle around detected heads")
+	renderer := flag.String("renderer", "", "Change renderer drawing detected faces, like blur, pixelate, box, emoji or anonymize")
+	rendererParams := make(paramsFlag)
+	flag.Var(rendererParams, "renderer-param", "Renderer parameter as key=value, like radius=12 for blur. Can be repeated")
 
 	camera := flag.Int("camera", 0, "Change active camera number")
+	cameras := flag.String("cameras", "", "Comma separated numbers of cameras to run detection on simultaneously, the first one being the main camera")
+
+	source := flag.String("source", "", "Change frame source: camera, video, images or stream")
+	sourcePath := flag.String("source-path", "", "Video file, image directory or stream url for the frame source")
+
+	sampling := flag.String("sampling", "", "Change frame sampling policy: interval, frames or maxfps")
+	interval := flag.Duration("interval", 0, "Change capture interval (for interval policy), like 2s or 500ms")
+	frameStep := flag.Int("frame-step", 0, "Process one frame every N grabbed frames (for frames policy)")
+	maxFPS := flag.Float64("max-fps", 0, "Maximum number of processed frames per second (for maxfps policy)")
+	motionThreshold := flag.Float64("motion-threshold", 0, "Ratio of changed pixels (0-1) considered as motion (for motion gate)")
+	motionGate := flag.Bool("motion-gate", false, "Only run detection on sampled frames when the scene changed")
+	noMotionGate := flag.Bool("no-motion-gate", false, "Run detection on every sampled frame")
+
+	retentionDays := flag.Int("retention-days", 0, "Drop raw stats older than this number of days (-1 to keep them forever)")
+	downsample := flag.Bool("downsample", false, "Keep hourly aggregates of dropped raw stats")
+	noDownsample := flag.Bool("no-downsample", false, "Don't keep any aggregate of dropped raw stats")
+
+	privacy := flag.Bool("privacy", false, "Never save raw frames and anonymize faces on every saved image")
+	noPrivacy := flag.Bool("no-privacy", false, "Disable privacy mode")
+	privacyMethod := flag.String("privacy-method", "", "Change how faces are anonymized in privacy mode: blur or pixelate")
+
+	detector := flag.String("detector", "", "Change face detector: haar (OpenCV) or pico (pure Go, needs the facefinder model)")
+
+	cascades := flag.String("cascades", "", "Comma separated haar cascade models: frontal, profile, eyes, upperbody or cascade file names")
+	scaleFactor := flag.Float64("scale-factor", 0, "Haar detection scale factor between passes, greater than 1")
+	minNeighbors := flag.Int("min-neighbors", 0, "Haar detection overlapping candidates needed to retain a face (-1 for none)")
+	minSize := flag.Int("min-size", 0, "Minimum face size in pixels for haar detection (-1 for no bound)")
+	maxSize := flag.Int("max-size", 0, "Maximum face size in pixels for haar detection (-1 for no bound)")
+	nmsThreshold := flag.Float64("nms-threshold", 0, "Overlap ratio (0-1) above which faces found by different models are merged")
 
 	quit := flag.Bool("quit", false, "Force the web server to shutdown")
 
@@ -33,20 +91,162 @@ func main() {
 	if *funMode && *normalMode {
 		errorOut("fun and normal rendering mode can't be set at the same time")
 	}
+	if *renderer != "" && (*funMode || *normalMode) {
+		errorOut("renderer can't be set with fun or normal rendering mode")
+	}
+	if *renderer == "" && len(rendererParams) > 0 {
+		errorOut("renderer parameters need a renderer")
+	}
+
+	sourceKind, ok := messages.FrameSources[*source]
+	if !ok && *source != "" {
+		errorOut(fmt.Sprintf("unknown frame source: %s", *source))
+	}
+
+	samplingPolicy, ok := messages.SamplingPolicies[*sampling]
+	if !ok && *sampling != "" {
+		errorOut(fmt.Sprintf("unknown sampling policy: %s", *sampling))
+	}
+	if *interval < 0 || *frameStep < 0 || *maxFPS < 0 || *motionThreshold < 0 || *motionThreshold > 1 {
+		errorOut("invalid sampling parameters")
+	}
+	// the interval is sent in milliseconds: a shorter one would be ignored
+	if *interval > 0 && *interval < time.Millisecond {
+		errorOut("interval should be at least 1ms")
+	}
+
+	if *downsample && *noDownsample {
+		errorOut("downsample and no-downsample can't be set at the same time")
+	}
+	if *motionGate && *noMotionGate {
+		errorOut("motion-gate and no-motion-gate can't be set at the same time")
+	}
+
+	if *privacy && *noPrivacy {
+		errorOut("privacy and no-privacy can't be set at the same time")
+	}
+	privacyMethodValue, ok := messages.PrivacyMethods[*privacyMethod]
+	if !ok && *privacyMethod != "" {
+		errorOut(fmt.Sprintf("unknown privacy method: %s", *privacyMethod))
+	}
+
+	detectorValue, ok := messages.Detectors[*detector]
+	if !ok && *detector != "" {
+		errorOut(fmt.Sprintf("unknown detector: %s", *detector))
+	}
+
+	var cascadeModels []string
+	for _, m := range strings.Split(*cascades, ",") {
+		if m = strings.TrimSpace(m); m != "" {
+			cascadeModels = append(cascadeModels, m)
+		}
+	}
+	if *scaleFactor < 0 || (*scaleFactor > 0 && *scaleFactor <= 1) || *nmsThreshold < 0 || *nmsThreshold > 1 {
+		errorOut("invalid haar detection parameters")
+	}
+
+	var activeCameras []int32
+	for _, c := range strings.Split(*cameras, ",") {
+		if c = strings.TrimSpace(c); c == "" {
+			continue
+		}
+		n, err := strconv.Atoi(c)
+		if err != nil || n < 1 {
+			errorOut(fmt.Sprintf("invalid camera number: %s", c))
+		}
+		activeCameras = append(activeCameras, int32(n))
+	}
+	if *camera != 0 && len(activeCameras) > 0 {
+		errorOut("camera and cameras can't be set at the same time")
+	}
 
 	msg := createMessage(*enableCam, *disableCam, *funMode, *normalMode, *camera, *quit)
+	if *renderer != "" {
+		msg.Renderer = &messages.Renderer{Name: *renderer, Params: rendererParams}
+	}
+	if len(activeCameras) > 0 {
+		msg.ActiveCameras = &messages.ActiveCameras{Cameras: activeCameras}
+	}
+	msg.Source = sourceKind
+	msg.SourcePath = *sourcePath
+	msg.Sampling = samplingPolicy
+	msg.CaptureIntervalMs = int32(*interval / time.Millisecond)
+	msg.FrameStep = int32(*frameStep)
+	msg.MaxFPS = float32(*maxFPS)
+	msg.MotionThreshold = float32(*motionThreshold)
+	if *motionGate {
+		msg.MotionGate = messages.Action_MOTIONGATE_ENABLE
+	} else if *noMotionGate {
+		msg.MotionGate = messages.Action_MOTIONGATE_DISABLE
+	}
+	msg.RetentionDays = int32(*retentionDays)
+	if *downsample {
+		msg.Downsampling = messages.Action_DOWNSAMPLING_ENABLE
+	} else if *noDownsample {
+		msg.Downsampling = messages.Action_DOWNSAMPLING_DISABLE
+	}
+
+	if *privacy {
+		msg.Privacy = messages.Action_PRIVACY_ENABLE
+	} else if *noPrivacy {
+		msg.Privacy = messages.Action_PRIVACY_DISABLE
+	}
+	msg.PrivacyMethod = privacyMethodValue
+	msg.Detector = detectorValue
+	if len(cascadeModels) > 0 || *scaleFactor != 0 || *minNeighbors != 0 || *minSize != 0 || *maxSize != 0 || *nmsThreshold != 0 {
+		msg.Cascades = &messages.HaarCascades{
+			Models:       cascadeModels,
+			ScaleFactor:  float32(*scaleFactor),
+			MinNeighbors: int32(*minNeighbors),
+			MinSize:      int32(*minSize),
+			MaxSize:      int32(*maxSize),
+			NmsThreshold: float32(*nmsThreshold),
+		}
+	}
 
-	if err := comm.SendToSocket(msg); err != nil {
+	resp, err := comm.SendToSocket(&messages.Request{Action: msg})
+	if err != nil {
 		os.Exit(1)
 	}
+	if !resp.Success {
+		fmt.Println("Error:", resp.Error)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage of %s:\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  %s [options]\tchange service settings\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  %s status\tprint current state of the service\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  %s watch [options]\tprint service events as they happen (see watch -h)\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  %s cameras [list|set]\tlist cameras and change their capture settings (see cameras -h)\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  %s zones [list|set|delete]\tmanage zones persons are counted in (see zones -h)\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  %s export [options]\texport collected stats (see export -h)\n\n", os.Args[0])
+	flag.PrintDefaults()
 }
 
 func errorOut(message string) {
 	fmt.Println("Error:", message)
-	flag.PrintDefaults()
+	flag.Usage()
 	os.Exit(1)
 }
 
+// paramsFlag collects repeated key=value flags
+type paramsFlag map[string]string
+
+func (p paramsFlag) String() string {
+	return fmt.Sprint(map[string]string(p))
+}
+
+func (p paramsFlag) Set(value string) error {
+	kv := strings.SplitN(value, "=", 2)
+	if len(kv) != 2 || kv[0] == "" {
+		return fmt.Errorf("expected key=value, got %q", value)
+	}
+	p[kv[0]] = kv[1]
+	return nil
+}
+
 func createMessage(enablefd bool, disablefd bool, fun bool, normal bool, camera int, quit bool) *messages.Action {
 	var cameraState messages.Action_FaceDetectionState
 	var renderingMode messages.Action_RenderingMode
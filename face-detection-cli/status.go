@@ -0,0 +1,86 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ubuntu/face-detection-demo/comm"
+	"github.com/ubuntu/face-detection-demo/messages"
+)
+
+// status prints current state of the service
+func status(args []string) {
+	flags := flag.NewFlagSet("status", flag.ExitOnError)
+	flags.Parse(args)
+	if flags.NArg() > 0 {
+		subcommandErrorOut(flags, "Invalid argument set")
+	}
+
+	resp, err := comm.SendToSocket(&messages.Request{})
+	if err != nil {
+		os.Exit(1)
+	}
+	if !resp.Success || resp.Status == nil {
+		fmt.Println("Couldn't get service status:", resp.Error)
+		os.Exit(1)
+	}
+	printStatus(resp.Status)
+}
+
+func printStatus(s *messages.Status) {
+	detection := "disabled"
+	if s.DetectionState == "failed" {
+		detection = fmt.Sprintf("failed (%s)", s.DetectionError)
+	} else if s.DetectionState == "starting" || s.DetectionState == "stopping" {
+		detection = s.DetectionState
+	} else if s.FaceDetection && s.Running {
+		detection = "running"
+	} else if s.FaceDetection && s.CameraStatus == "nocamera" {
+		detection = "enabled, waiting for a camera"
+	} else if s.FaceDetection {
+		detection = "enabled, not running"
+	}
+	source := s.Source
+	if s.SourcePath != "" {
+		source = fmt.Sprintf("%s (%s)", s.Source, s.SourcePath)
+	}
+	laststat := "none"
+	if s.LastStatTime != 0 {
+		t := time.Unix(0, s.LastStatTime*int64(time.Millisecond))
+		laststat = fmt.Sprintf("%d persons at %s", s.LastStatPersons, t.Format(time.RFC3339))
+	}
+
+	fmt.Println("Detection:     ", detection)
+	fmt.Println("Source:        ", source)
+	fmt.Println("Detector:      ", s.Detector)
+	fmt.Println("Camera:        ", s.Camera)
+	if len(s.ActiveCameras) > 1 {
+		fmt.Println("Cameras:       ", joinCameras(s.ActiveCameras))
+	}
+	if len(s.RunningCameras) > 0 && s.Source == "camera" {
+		fmt.Println("Running on:    ", joinCameras(s.RunningCameras))
+	}
+	switch s.CameraStatus {
+	case "nocamera":
+		fmt.Println("Camera state:   no camera detected")
+	case "camerafailed":
+		fmt.Println("Camera state:   camera", s.FailedCamera, "failed:", s.CameraError)
+	}
+	fmt.Println("Rendering mode:", s.RenderingMode)
+	fmt.Println("Privacy mode:  ", s.Privacy)
+	fmt.Println("Clients:       ", s.Clients)
+	fmt.Println("Last stat:     ", laststat)
+}
+
+// joinCameras formats camera numbers as a comma separated list
+func joinCameras(cameras []int32) string {
+	var numbers []string
+	for _, c := range cameras {
+		numbers = append(numbers, strconv.Itoa(int(c)))
+	}
+	return strings.Join(numbers, ", ")
+}
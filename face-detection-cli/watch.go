@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ubuntu/face-detection-demo/comm"
+	"github.com/ubuntu/face-detection-demo/messages"
+)
+
+// watch prints service events as they happen
+func watch(args []string) {
+	flags := flag.NewFlagSet("watch", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "Print one json event per line instead of human readable lines")
+	types := flags.String("types", "", "Comma separated event types to print (newstat, facedetection, renderingmode, "+
+		"newcameraactivated, activecameras, availablecameras, cameralost, camerareconnected, camerastate, "+
+		"detectionstate, cameraparams, framesource, sampling, retention, privacy, detector, cascades, visit, zones). "+
+		"All by default")
+	flags.Parse(args)
+	if flags.NArg() > 0 {
+		subcommandErrorOut(flags, "Invalid argument set")
+	}
+
+	var filter []string
+	for _, t := range strings.Split(*types, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			filter = append(filter, t)
+		}
+	}
+
+	err := comm.WatchEvents(filter, func(e *messages.Event) {
+		if *asJSON {
+			fmt.Println(string(e.Json))
+			return
+		}
+		printEvent(e)
+	})
+	fmt.Println("Disconnected from service:", err)
+	os.Exit(1)
+}
+
+func printEvent(e *messages.Event) {
+	var msg messages.WSMessage
+	if err := json.Unmarshal(e.Json, &msg); err != nil {
+		fmt.Println("Couldn't decode event:", err)
+		return
+	}
+
+	var desc string
+	switch msg.Type {
+	case "newstat":
+		desc = fmt.Sprintf("%d persons", msg.NewStat.NumPersons)
+		if msg.Camera != 0 {
+			desc += fmt.Sprintf(" on camera %d", msg.Camera)
+		}
+		for zone, n := range msg.NewStat.Zones {
+			desc += fmt.Sprintf(" (%d in %s)", n, zone)
+		}
+		for _, d := range msg.Detections {
+			desc += fmt.Sprintf(" [%dx%d at %d,%d]", d.Width, d.Height, d.X, d.Y)
+		}
+	case "facedetection":
+		desc = "detection disabled"
+		if msg.FaceDetection {
+			desc = "detection enabled"
+		}
+	case "renderingmode":
+		desc = fmt.Sprintf("renderer set to %s", msg.Renderer.Name)
+		if len(msg.Renderer.Params) > 0 {
+			desc += fmt.Sprintf(" %v", msg.Renderer.Params)
+		}
+	case "newcameraactivated":
+		desc = fmt.Sprintf("camera %d activated", msg.Camera)
+	case "activecameras":
+		desc = fmt.Sprintf("detection set to run on cameras %v", msg.ActiveCameras)
+	case "availablecameras":
+		desc = fmt.Sprintf("available cameras are now %v", msg.AvailableCameras)
+	case "cameralost":
+		desc = fmt.Sprintf("camera %d lost, trying to reopen it", msg.Camera)
+	case "camerareconnected":
+		desc = fmt.Sprintf("camera %d reconnected", msg.Camera)
+	case "detectionstate":
+		desc = fmt.Sprintf("detection is now %s", msg.DetectionState)
+		if msg.DetectionError != "" {
+			desc = fmt.Sprintf("%s: %s", desc, msg.DetectionError)
+		}
+	case "camerastate":
+		desc = fmt.Sprintf("camera state is now %s", msg.CameraState.Status)
+		if msg.CameraState.Error != "" {
+			desc = fmt.Sprintf("%s: %s", desc, msg.CameraState.Error)
+		}
+	case "cameraparams":
+		desc = fmt.Sprintf("capture settings of camera %d set to %+v", msg.Camera, msg.CameraParams[msg.Camera])
+	case "framesource":
+		desc = fmt.Sprintf("frame source set to %s %s", msg.Source, msg.SourcePath)
+	case "sampling":
+		desc = fmt.Sprintf("sampling set to %+v", *msg.Sampling)
+	case "retention":
+		desc = fmt.Sprintf("retention set to %+v", *msg.Retention)
+	case "detector":
+		desc = fmt.Sprintf("face detector set to %s", msg.Detector)
+	case "zones":
+		var names []string
+		for _, z := range msg.Zones {
+			names = append(names, z.Name)
+		}
+		desc = fmt.Sprintf("zones set to %s", strings.Join(names, ", "))
+	case "visit":
+		desc = fmt.Sprintf("visitor %d stayed %s", msg.Visit.TrackID, msg.Visit.Dwell())
+	case "cascades":
+		desc = fmt.Sprintf("haar cascades set to %+v", *msg.Cascades)
+	case "privacy":
+		desc = fmt.Sprintf("privacy set to %+v", *msg.Privacy)
+	default:
+		desc = string(e.Json)
+	}
+	fmt.Printf("%s %s: %s\n", time.Now().Format("15:04:05"), msg.Type, desc)
+}
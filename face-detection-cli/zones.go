@@ -0,0 +1,98 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ubuntu/face-detection-demo/comm"
+	"github.com/ubuntu/face-detection-demo/messages"
+)
+
+// zones lists, creates, replaces or deletes zones persons are counted in
+func zones(args []string) {
+	flags := flag.NewFlagSet("zones", flag.ExitOnError)
+	camera := flags.Int("camera", 0, "Restrict the zone set to this camera number. All frames by default")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage of %s zones:\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s zones [list]\tlist zones\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s zones [-camera N] set NAME X,Y X,Y X,Y…\tcreate or replace a zone. Coordinates are ratios (0-1) of frame width and height\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s zones delete NAME\tdelete a zone\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if *camera < 0 {
+		subcommandErrorOut(flags, "invalid camera number")
+	}
+
+	cmd := "list"
+	if flags.NArg() > 0 {
+		cmd = flags.Arg(0)
+	}
+
+	req := &messages.Request{}
+	switch {
+	case cmd == "list" && flags.NArg() <= 1:
+	case cmd == "set" && flags.NArg() >= 5:
+		zone := &messages.Zone{Name: flags.Arg(1), Camera: int32(*camera)}
+		for _, arg := range flags.Args()[2:] {
+			p, err := parsePoint(arg)
+			if err != nil {
+				subcommandErrorOut(flags, err.Error())
+			}
+			zone.Points = append(zone.Points, p)
+		}
+		req.Action = &messages.Action{SetZone: zone}
+	case cmd == "delete" && flags.NArg() == 2:
+		req.Action = &messages.Action{DeleteZone: flags.Arg(1)}
+	default:
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	resp, err := comm.SendToSocket(req)
+	if err != nil {
+		os.Exit(1)
+	}
+	if !resp.Success {
+		fmt.Println("Error:", resp.Error)
+		os.Exit(1)
+	}
+	if req.Action != nil || resp.Status == nil {
+		return
+	}
+
+	if len(resp.Status.Zones) == 0 {
+		fmt.Println("No zone defined")
+	}
+	for _, z := range resp.Status.Zones {
+		var points []string
+		for _, p := range z.Points {
+			points = append(points, fmt.Sprintf("%g,%g", p.X, p.Y))
+		}
+		desc := strings.Join(points, " ")
+		if z.Camera != 0 {
+			desc += fmt.Sprintf(" (camera %d)", z.Camera)
+		}
+		fmt.Printf("%s: %s\n", z.Name, desc)
+	}
+}
+
+// parsePoint parses X,Y coordinates
+func parsePoint(s string) (*messages.Point, error) {
+	coords := strings.Split(s, ",")
+	if len(coords) != 2 {
+		return nil, fmt.Errorf("invalid point %q, should be X,Y", s)
+	}
+	x, err := strconv.ParseFloat(coords[0], 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid point %q: %s", s, err)
+	}
+	y, err := strconv.ParseFloat(coords[1], 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid point %q: %s", s, err)
+	}
+	return &messages.Point{X: x, Y: y}, nil
+}
@@ -1,12 +1,14 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"os"
 	"os/signal"
 	"sync"
 	"syscall"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 
@@ -18,6 +20,7 @@ import (
 )
 
 var (
+	controller       *detection.Controller
 	wgwebcam         *sync.WaitGroup
 	wgservices       *sync.WaitGroup
 	shutdownwebcam   chan interface{}
@@ -31,35 +34,35 @@ func main() {
 	deletesocket := flag.Bool("force", false, "Try force starting even if another daemon is running")
 	flag.Parse()
 
-	// check if we are in broken mode and remove database if it's the case
+	// check if we are in broken mode. Data is kept: the database is backed up before being migrated, so that
+	// reverting restores it
 	appstate.CheckIfBroken(appstate.Rootdir)
-	if appstate.BrokenMode {
-		datastore.WipeDB(appstate.Datadir)
-		detection.WipeScreenshots(appstate.Datadir)
-	}
 
 	// channels synchronization
 	wgwebcam = new(sync.WaitGroup)
 	wgservices = new(sync.WaitGroup)
 	shutdownwebcam = make(chan interface{})
 	shutdownservices = make(chan interface{})
+	controller = detection.NewController(appstate.Rootdir, shutdownwebcam, wgwebcam)
 
 	// handle user generated stop requests
 	userstop := make(chan os.Signal)
 	signal.Notify(userstop, syscall.SIGINT, syscall.SIGTERM)
 
 	actions := make(chan *messages.Action, 2)
+	requests := make(chan comm.SocketRequest)
 
 	// prepare settings and data
 	datastore.StartDB(appstate.Datadir, shutdownservices, wgservices)
 
 	// starts external communications channel
-	comm.StartSocketListener(actions, shutdownservices, *deletesocket, wgservices)
+	comm.StartSocketListener(requests, shutdownservices, *deletesocket, wgservices)
 	comm.StartServer(appstate.Rootdir, appstate.Datadir, actions)
+	controller.WatchCameras(shutdownservices, wgservices)
 
 	// starts camera if it was already started last time
 	if datastore.FaceDetection() {
-		detection.StartCameraDetect(appstate.Rootdir, shutdownwebcam, wgwebcam)
+		controller.QueueStart()
 	}
 
 mainloop:
@@ -67,7 +70,12 @@ mainloop:
 		select {
 		case action := <-actions:
 			fmt.Println("new action received")
-			if processaction(action) {
+			if stop, _, _ := processaction(action); stop {
+				break mainloop
+			}
+		case req := <-requests:
+			fmt.Println("new socket request received")
+			if stop := processrequest(req); stop {
 				break mainloop
 			}
 		case <-userstop:
@@ -81,42 +89,430 @@ mainloop:
 	wgservices.Wait()
 }
 
-// process action and return true if we need to quit (exit mainloop)
+// process socket request action if any and send back the response with current status, once detection start,
+// stop or restart requested by the action are done. Return true if we need to quit (exit mainloop)
+func processrequest(req comm.SocketRequest) bool {
+	resp := &messages.Response{Success: true}
+	stop := false
+	var operations []<-chan error
+	if req.Request.Action != nil {
+		var err error
+		if stop, operations, err = processaction(req.Request.Action); err != nil {
+			resp.Success = false
+			resp.Error = err.Error()
+		}
+	}
+	// services are shutting down, there is no status to report
+	if stop {
+		req.Response <- resp
+		return stop
+	}
+
+	// don't block the main loop while frame sources are opened or closed
+	go func() {
+		for _, op := range operations {
+			if err := <-op; err != nil && resp.Success {
+				resp.Success = false
+				resp.Error = err.Error()
+			}
+		}
+		resp.Status = currentStatus()
+		req.Response <- resp
+	}()
+	return stop
+}
+
+// currentStatus returns the state of the service
+func currentStatus() *messages.Status {
+	kind, sourcepath := datastore.FrameSource()
+	status := &messages.Status{
+		FaceDetection: datastore.FaceDetection(),
+		Running:       controller.Running(),
+		// camera is offsetted by 1 for the client
+		Camera:        int32(datastore.Camera() + 1),
+		RenderingMode: datastore.FaceRenderer().Name,
+		Source:        string(kind),
+		SourcePath:    sourcepath,
+		Clients:       int32(comm.WSserv.NumClients()),
+		Privacy:       datastore.PrivacyMode().Enabled,
+		Detector:      string(datastore.DetectorBackend()),
+	}
+	state, stateErr := controller.State()
+	status.DetectionState = string(state)
+	if stateErr != nil {
+		status.DetectionError = stateErr.Error()
+	}
+	cameraState := appstate.CurrentCameraState()
+	status.CameraStatus = string(cameraState.Status)
+	status.FailedCamera = int32(cameraState.Camera)
+	status.CameraError = cameraState.Error
+	for _, z := range datastore.Zones() {
+		status.Zones = append(status.Zones, messages.ZoneToMessage(z))
+	}
+	for _, c := range messages.ClientCameras(datastore.Cameras()) {
+		status.ActiveCameras = append(status.ActiveCameras, int32(c))
+	}
+	for _, c := range messages.ClientCameras(controller.RunningCameras()) {
+		status.RunningCameras = append(status.RunningCameras, int32(c))
+	}
+	for _, info := range appstate.CameraInfos() {
+		// camera is offsetted by 1 for the client
+		status.Cameras = append(status.Cameras, messages.CameraInfoToMessage(info, datastore.CameraParameters(info.Camera-1)))
+	}
+	stats, _, err := datastore.DB.StatsBefore(0, 1)
+	if err != nil {
+		fmt.Println("Couldn't fetch last stat:", err)
+	} else if len(stats) > 0 {
+		status.LastStatTime = stats[0].TimeStamp.UnixNano() / int64(time.Millisecond)
+		status.LastStatPersons = int32(stats[0].NumPersons)
+	}
+	return status
+}
+
+// process action and return true if we need to quit (exit mainloop). Invalid changes are ignored and reported
+// in the returned error, other ones are still applied. Detection start, stop and restart are queued in order to
+// the controller, their outcome being sent to returned channels.
 // TODO: use quit channel (renamed userstop to quit) and send data there. Remove the bool True/False
-func processaction(action *messages.Action) bool {
+func processaction(action *messages.Action) (bool, []<-chan error, error) {
+	var err error
+	var operations []<-chan error
 	if action.FaceDetection == messages.Action_FACEDETECTION_ENABLE {
-		detection.StartCameraDetect(appstate.Rootdir, shutdownwebcam, wgwebcam)
 		fmt.Println("Received camera on")
+		operations = append(operations, controller.QueueStart())
 	} else if action.FaceDetection == messages.Action_FACEDETECTION_DISABLE {
-		detection.EndCameraDetect()
 		fmt.Println("Received camera off")
+		operations = append(operations, controller.QueueStop())
 	}
-	if action.RenderingMode == messages.Action_RENDERINGMODE_FUN {
-		datastore.SetRenderingMode(datastore.FUNRENDERING)
-		comm.WSserv.SendAllClients(&messages.WSMessage{
-			Type:          "renderingmode",
-			RenderingMode: datastore.RenderingMode()})
-	} else if action.RenderingMode == messages.Action_RENDERINGMODE_NORMAL {
-		datastore.SetRenderingMode(datastore.NORMALRENDERING)
-		comm.WSserv.SendAllClients(&messages.WSMessage{
-			Type:          "renderingmode",
-			RenderingMode: datastore.RenderingMode()})
+	if renderer, changed := rendererFromAction(action); changed {
+		if rerr := detection.ValidateRenderer(renderer.Name); rerr != nil {
+			fmt.Println("Ignoring renderer change:", rerr)
+			err = rerr
+		} else if datastore.SetFaceRenderer(renderer) {
+			comm.WSserv.SendAllClients(&messages.WSMessage{
+				Type:     "renderingmode",
+				Renderer: &renderer})
+		}
 	}
 	// camera is offsetted by 1 for the client (0, protobuf default means no change)
 	cameranum := int(action.Camera) - 1
 	if cameranum > -1 && cameranum != datastore.Camera() {
 		datastore.SetCamera(cameranum)
 		comm.WSserv.SendAllClients(&messages.WSMessage{
-			Type:   "newcameraactivated",
-			Camera: cameranum + 1})
+			Type:          "newcameraactivated",
+			Camera:        cameranum + 1,
+			ActiveCameras: messages.ClientCameras(datastore.Cameras())})
 		if datastore.FaceDetection() {
 			fmt.Println("Change active camera")
-			go detection.RestartCamera(appstate.Rootdir, shutdownwebcam, wgwebcam)
+			operations = append(operations, controller.QueueRestart())
+		}
+	}
+	if action.ActiveCameras != nil {
+		// cameras are offsetted by 1 for the client
+		var cameras []int
+		for _, c := range action.ActiveCameras.Cameras {
+			cameras = append(cameras, int(c)-1)
+		}
+		if cerr := datastore.ValidateCameras(cameras); cerr != nil {
+			fmt.Println("Ignoring invalid active cameras:", cerr)
+			err = fmt.Errorf("invalid active cameras: %s", cerr)
+		} else if datastore.SetCameras(cameras) {
+			comm.WSserv.SendAllClients(&messages.WSMessage{
+				Type:          "activecameras",
+				Camera:        datastore.Camera() + 1,
+				ActiveCameras: messages.ClientCameras(cameras)})
+			if datastore.FaceDetection() {
+				fmt.Println("Change active cameras")
+				operations = append(operations, controller.QueueRestart())
+			}
+		}
+	}
+	if camera, params, changed := cameraParamsFromAction(action); changed {
+		if camera < 0 {
+			fmt.Println("Ignoring capture settings without camera")
+			err = errors.New("invalid capture settings: camera number is needed")
+		} else if perr := params.Validate(); perr != nil {
+			fmt.Println("Ignoring invalid capture settings:", perr)
+			err = fmt.Errorf("invalid capture settings: %s", perr)
+		} else if datastore.SetCameraParameters(camera, params) {
+			comm.WSserv.SendAllClients(&messages.WSMessage{
+				Type:         "cameraparams",
+				Camera:       camera + 1,
+				CameraParams: messages.ClientCameraParams(datastore.AllCameraParameters())})
+			if datastore.FaceDetection() && isActiveCamera(camera) {
+				fmt.Println("Change capture settings")
+				operations = append(operations, controller.QueueRestart())
+			}
+		}
+	}
+	if action.Source != messages.Action_SOURCE_UNCHANGED || action.SourcePath != "" {
+		kind, sourcepath := datastore.FrameSource()
+		switch action.Source {
+		case messages.Action_SOURCE_CAMERA:
+			kind = datastore.CAMERASOURCE
+		case messages.Action_SOURCE_VIDEO:
+			kind = datastore.VIDEOSOURCE
+		case messages.Action_SOURCE_IMAGES:
+			kind = datastore.IMAGESSOURCE
+		case messages.Action_SOURCE_STREAM:
+			kind = datastore.STREAMSOURCE
+		}
+		if action.SourcePath != "" {
+			sourcepath = action.SourcePath
+		}
+		if datastore.SetFrameSource(kind, sourcepath) {
+			comm.WSserv.SendAllClients(&messages.WSMessage{
+				Type:       "framesource",
+				Source:     kind,
+				SourcePath: sourcepath})
+			if datastore.FaceDetection() {
+				fmt.Println("Change frame source")
+				operations = append(operations, controller.QueueRestart())
+			} else {
+				// cameras are only needed by camera sources
+				controller.UpdateCameraState()
+			}
+		}
+	}
+	if sampling, changed := samplingFromAction(action); changed {
+		if serr := sampling.Validate(); serr != nil {
+			fmt.Println("Ignoring invalid sampling settings:", serr)
+			err = fmt.Errorf("invalid sampling settings: %s", serr)
+		} else if datastore.SetFrameSampling(sampling) {
+			comm.WSserv.SendAllClients(&messages.WSMessage{
+				Type:     "sampling",
+				Sampling: &sampling})
+		}
+	}
+	if action.RetentionDays != 0 || action.Downsampling != messages.Action_DOWNSAMPLING_UNCHANGED {
+		retention := datastore.DataRetention()
+		if action.RetentionDays > 0 {
+			retention.RawDays = int(action.RetentionDays)
+		} else if action.RetentionDays < 0 {
+			retention.RawDays = 0
+		}
+		if action.Downsampling == messages.Action_DOWNSAMPLING_ENABLE {
+			retention.Downsample = true
+		} else if action.Downsampling == messages.Action_DOWNSAMPLING_DISABLE {
+			retention.Downsample = false
+		}
+		if datastore.SetDataRetention(retention) {
+			comm.WSserv.SendAllClients(&messages.WSMessage{
+				Type:      "retention",
+				Retention: &retention})
+		}
+	}
+	if action.Privacy != messages.Action_PRIVACY_UNCHANGED || action.PrivacyMethod != messages.Action_PRIVACYMETHOD_UNCHANGED {
+		privacy := datastore.PrivacyMode()
+		if action.Privacy == messages.Action_PRIVACY_ENABLE {
+			privacy.Enabled = true
+		} else if action.Privacy == messages.Action_PRIVACY_DISABLE {
+			privacy.Enabled = false
+		}
+		if action.PrivacyMethod == messages.Action_PRIVACYMETHOD_BLUR {
+			privacy.Method = datastore.BLURPRIVACY
+		} else if action.PrivacyMethod == messages.Action_PRIVACYMETHOD_PIXELATE {
+			privacy.Method = datastore.PIXELATEPRIVACY
+		}
+		wasEnabled := datastore.PrivacyMode().Enabled
+		if datastore.SetPrivacyMode(privacy) {
+			// screenshots saved before may show faces
+			if privacy.Enabled && !wasEnabled {
+				detection.WipeScreenshots(appstate.Datadir)
+			}
+			comm.WSserv.SendAllClients(&messages.WSMessage{
+				Type:    "privacy",
+				Privacy: &privacy})
+		}
+	}
+	if action.Detector != messages.Action_DETECTOR_UNCHANGED {
+		kind := datastore.HAARDETECTOR
+		if action.Detector == messages.Action_DETECTOR_PICO {
+			kind = datastore.PICODETECTOR
+		}
+		if datastore.SetDetectorBackend(kind) {
+			comm.WSserv.SendAllClients(&messages.WSMessage{
+				Type:     "detector",
+				Detector: kind})
+			if datastore.FaceDetection() {
+				fmt.Println("Change face detector")
+				operations = append(operations, controller.QueueRestart())
+			}
+		}
+	}
+	if cascades, changed := cascadesFromAction(action); changed {
+		if cerr := cascades.Validate(); cerr != nil {
+			fmt.Println("Ignoring invalid cascades settings:", cerr)
+			err = fmt.Errorf("invalid cascades settings: %s", cerr)
+		} else if datastore.SetHaarCascades(cascades) {
+			comm.WSserv.SendAllClients(&messages.WSMessage{
+				Type:     "cascades",
+				Cascades: &cascades})
+			if datastore.FaceDetection() && datastore.DetectorBackend() == datastore.HAARDETECTOR {
+				fmt.Println("Change haar cascades")
+				operations = append(operations, controller.QueueRestart())
+			}
+		}
+	}
+	if action.SetZone != nil {
+		zone := messages.ZoneFromMessage(action.SetZone)
+		if zerr := zone.Validate(); zerr != nil {
+			fmt.Println("Ignoring invalid zone:", zerr)
+			err = fmt.Errorf("invalid zone: %s", zerr)
+		} else if datastore.SetZone(zone) {
+			comm.WSserv.SendAllClients(&messages.WSMessage{
+				Type:  "zones",
+				Zones: datastore.Zones()})
+		}
+	}
+	if action.DeleteZone != "" {
+		if datastore.DeleteZone(action.DeleteZone) {
+			comm.WSserv.SendAllClients(&messages.WSMessage{
+				Type:  "zones",
+				Zones: datastore.Zones()})
+		} else {
+			fmt.Println("Can't delete unknown zone", action.DeleteZone)
+			err = fmt.Errorf("unknown zone: %s", action.DeleteZone)
 		}
 	}
 	if action.QuitServer {
 		quit()
-		return true
+		return true, operations, err
+	}
+	return false, operations, err
+}
+
+// renderer requested by action, or by its legacy rendering mode. Return true if the action requested any change
+func rendererFromAction(action *messages.Action) (datastore.Renderer, bool) {
+	if action.Renderer != nil {
+		return datastore.Renderer{Name: action.Renderer.Name, Params: action.Renderer.Params}, true
+	}
+	switch action.RenderingMode {
+	case messages.Action_RENDERINGMODE_NORMAL:
+		return datastore.Renderer{Name: datastore.NORMALRENDERING}, true
+	case messages.Action_RENDERINGMODE_FUN:
+		return datastore.Renderer{Name: datastore.FUNRENDERING}, true
+	}
+	return datastore.Renderer{}, false
+}
+
+// merge sampling parameters from action with current ones. Return true if the action requested any change
+func samplingFromAction(action *messages.Action) (datastore.Sampling, bool) {
+	sampling := datastore.FrameSampling()
+	changed := true
+	switch action.Sampling {
+	case messages.Action_SAMPLING_INTERVAL:
+		sampling.Policy = datastore.INTERVALSAMPLING
+	case messages.Action_SAMPLING_FRAMES:
+		sampling.Policy = datastore.FRAMESSAMPLING
+	case messages.Action_SAMPLING_MAXFPS:
+		sampling.Policy = datastore.MAXFPSSAMPLING
+	default:
+		changed = false
+	}
+	if action.CaptureIntervalMs != 0 {
+		sampling.IntervalMs = int(action.CaptureIntervalMs)
+		changed = true
+	}
+	if action.FrameStep != 0 {
+		sampling.FrameStep = int(action.FrameStep)
+		changed = true
+	}
+	if action.MaxFPS != 0 {
+		sampling.MaxFPS = float64(action.MaxFPS)
+		changed = true
+	}
+	if action.MotionThreshold != 0 {
+		sampling.MotionThreshold = float64(action.MotionThreshold)
+		changed = true
+	}
+	switch action.MotionGate {
+	case messages.Action_MOTIONGATE_ENABLE:
+		sampling.MotionGate = true
+		changed = true
+	case messages.Action_MOTIONGATE_DISABLE:
+		sampling.MotionGate = false
+		changed = true
+	}
+	return sampling, changed
+}
+
+// merge haar cascades models and parameters from action with current ones. Return true if the action requested any
+// change
+func cascadesFromAction(action *messages.Action) (datastore.Cascades, bool) {
+	cascades := datastore.HaarCascades()
+	c := action.Cascades
+	if c == nil {
+		return cascades, false
+	}
+	if len(c.Models) > 0 {
+		cascades.Models = c.Models
+	}
+	if c.ScaleFactor != 0 {
+		cascades.ScaleFactor = float64(c.ScaleFactor)
+	}
+	if c.MinNeighbors > 0 {
+		cascades.MinNeighbors = int(c.MinNeighbors)
+	} else if c.MinNeighbors < 0 {
+		cascades.MinNeighbors = 0
+	}
+	if c.MinSize > 0 {
+		cascades.MinSize = int(c.MinSize)
+	} else if c.MinSize < 0 {
+		cascades.MinSize = 0
+	}
+	if c.MaxSize > 0 {
+		cascades.MaxSize = int(c.MaxSize)
+	} else if c.MaxSize < 0 {
+		cascades.MaxSize = 0
+	}
+	if c.NmsThreshold != 0 {
+		cascades.NMSThreshold = float64(c.NmsThreshold)
+	}
+	return cascades, true
+}
+
+// merge capture settings of the camera from action with current ones. Return true if the action requested any change
+func cameraParamsFromAction(action *messages.Action) (int, datastore.CameraParams, bool) {
+	p := action.CameraParams
+	if p == nil {
+		return 0, datastore.CameraParams{}, false
+	}
+	// camera is offsetted by 1 for the client
+	camera := int(p.Camera) - 1
+	params := datastore.CameraParameters(camera)
+	if p.Width > 0 {
+		params.Width = int(p.Width)
+	} else if p.Width < 0 {
+		params.Width = 0
+	}
+	if p.Height > 0 {
+		params.Height = int(p.Height)
+	} else if p.Height < 0 {
+		params.Height = 0
+	}
+	if p.Fps > 0 {
+		params.FPS = p.Fps
+	} else if p.Fps < 0 {
+		params.FPS = 0
+	}
+	if p.AutoExposure {
+		params.Exposure = 0
+	} else if p.Exposure != 0 {
+		params.Exposure = p.Exposure
+	}
+	return camera, params, true
+}
+
+// isActiveCamera returns true if detection should run on camera
+func isActiveCamera(camera int) bool {
+	kind, _ := datastore.FrameSource()
+	if kind != datastore.CAMERASOURCE {
+		return false
+	}
+	for _, c := range datastore.Cameras() {
+		if c == camera {
+			return true
+		}
 	}
 	return false
 }
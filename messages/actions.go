@@ -0,0 +1,88 @@
+package messages
+
+import (
+	"github.com/ubuntu/face-detection-demo/appstate"
+	"github.com/ubuntu/face-detection-demo/datastore"
+)
+
+// FrameSources maps frame source names to their action value
+var FrameSources = map[string]Action_FrameSource{
+	"camera": Action_SOURCE_CAMERA,
+	"video":  Action_SOURCE_VIDEO,
+	"images": Action_SOURCE_IMAGES,
+	"stream": Action_SOURCE_STREAM,
+}
+
+// SamplingPolicies maps sampling policy names to their action value
+var SamplingPolicies = map[string]Action_SamplingPolicy{
+	"interval": Action_SAMPLING_INTERVAL,
+	"frames":   Action_SAMPLING_FRAMES,
+	"maxfps":   Action_SAMPLING_MAXFPS,
+}
+
+// PrivacyMethods maps privacy method names to their action value
+var PrivacyMethods = map[string]Action_PrivacyMethod{
+	"blur":     Action_PRIVACYMETHOD_BLUR,
+	"pixelate": Action_PRIVACYMETHOD_PIXELATE,
+}
+
+// Detectors maps face detector backend names to their action value
+var Detectors = map[string]Action_DetectorBackend{
+	"haar": Action_DETECTOR_HAAR,
+	"pico": Action_DETECTOR_PICO,
+}
+
+// ZoneToMessage converts a zone to its protobuf message
+func ZoneToMessage(z datastore.Zone) *Zone {
+	m := &Zone{Name: z.Name, Camera: int32(z.Camera)}
+	for _, p := range z.Points {
+		m.Points = append(m.Points, &Point{X: p.X, Y: p.Y})
+	}
+	return m
+}
+
+// ZoneFromMessage converts a zone protobuf message to a zone
+func ZoneFromMessage(m *Zone) datastore.Zone {
+	z := datastore.Zone{Name: m.Name, Camera: int(m.Camera)}
+	for _, p := range m.Points {
+		z.Points = append(z.Points, datastore.Point{X: p.X, Y: p.Y})
+	}
+	return z
+}
+
+// ClientCameras offsets camera numbers by 1 for clients
+func ClientCameras(cameras []int) []int {
+	result := make([]int, 0, len(cameras))
+	for _, c := range cameras {
+		result = append(result, c+1)
+	}
+	return result
+}
+
+// ClientCameraParams offsets camera numbers of capture settings by 1 for clients
+func ClientCameraParams(params map[int]datastore.CameraParams) map[int]datastore.CameraParams {
+	result := make(map[int]datastore.CameraParams, len(params))
+	for c, p := range params {
+		result[c+1] = p
+	}
+	return result
+}
+
+// CameraInfoToMessage converts a camera description and its capture settings to their protobuf message
+func CameraInfoToMessage(info appstate.CameraInfo, params datastore.CameraParams) *CameraInfo {
+	m := &CameraInfo{
+		Camera: int32(info.Camera),
+		Name:   info.Name,
+		Params: &CameraParams{
+			Camera:   int32(info.Camera),
+			Width:    int32(params.Width),
+			Height:   int32(params.Height),
+			Fps:      params.FPS,
+			Exposure: params.Exposure,
+		},
+	}
+	for _, mode := range info.Modes {
+		m.Modes = append(m.Modes, &CameraMode{Width: int32(mode.Width), Height: int32(mode.Height), Fps: mode.FPS})
+	}
+	return m
+}
@@ -10,6 +10,22 @@ It is generated from these files:
 
 It has these top-level messages:
 	Action
+	CameraParams
+	CameraInfo
+	CameraMode
+	ActiveCameras
+	Zone
+	Point
+	HaarCascades
+	Renderer
+	AggregateQuery
+	VisitQuery
+	HistoryQuery
+	Request
+	Subscription
+	Event
+	Response
+	Status
 */
 package messages
 
@@ -76,11 +92,210 @@ func (x Action_RenderingMode) String() string {
 }
 func (Action_RenderingMode) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{0, 1} }
 
+type Action_FrameSource int32
+
+const (
+	Action_SOURCE_UNCHANGED Action_FrameSource = 0
+	Action_SOURCE_CAMERA    Action_FrameSource = 1
+	Action_SOURCE_VIDEO     Action_FrameSource = 2
+	Action_SOURCE_IMAGES    Action_FrameSource = 3
+	Action_SOURCE_STREAM    Action_FrameSource = 4
+)
+
+var Action_FrameSource_name = map[int32]string{
+	0: "SOURCE_UNCHANGED",
+	1: "SOURCE_CAMERA",
+	2: "SOURCE_VIDEO",
+	3: "SOURCE_IMAGES",
+	4: "SOURCE_STREAM",
+}
+var Action_FrameSource_value = map[string]int32{
+	"SOURCE_UNCHANGED": 0,
+	"SOURCE_CAMERA":    1,
+	"SOURCE_VIDEO":     2,
+	"SOURCE_IMAGES":    3,
+	"SOURCE_STREAM":    4,
+}
+
+func (x Action_FrameSource) String() string {
+	return proto.EnumName(Action_FrameSource_name, int32(x))
+}
+func (Action_FrameSource) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{0, 2} }
+
+type Action_SamplingPolicy int32
+
+const (
+	Action_SAMPLING_UNCHANGED Action_SamplingPolicy = 0
+	Action_SAMPLING_INTERVAL  Action_SamplingPolicy = 1
+	Action_SAMPLING_FRAMES    Action_SamplingPolicy = 2
+	Action_SAMPLING_MAXFPS    Action_SamplingPolicy = 4
+)
+
+var Action_SamplingPolicy_name = map[int32]string{
+	0: "SAMPLING_UNCHANGED",
+	1: "SAMPLING_INTERVAL",
+	2: "SAMPLING_FRAMES",
+	4: "SAMPLING_MAXFPS",
+}
+var Action_SamplingPolicy_value = map[string]int32{
+	"SAMPLING_UNCHANGED": 0,
+	"SAMPLING_INTERVAL":  1,
+	"SAMPLING_FRAMES":    2,
+	"SAMPLING_MAXFPS":    4,
+}
+
+func (x Action_SamplingPolicy) String() string {
+	return proto.EnumName(Action_SamplingPolicy_name, int32(x))
+}
+func (Action_SamplingPolicy) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{0, 3} }
+
+type Action_MotionGateState int32
+
+const (
+	Action_MOTIONGATE_UNCHANGED Action_MotionGateState = 0
+	Action_MOTIONGATE_ENABLE    Action_MotionGateState = 1
+	Action_MOTIONGATE_DISABLE   Action_MotionGateState = 2
+)
+
+var Action_MotionGateState_name = map[int32]string{
+	0: "MOTIONGATE_UNCHANGED",
+	1: "MOTIONGATE_ENABLE",
+	2: "MOTIONGATE_DISABLE",
+}
+var Action_MotionGateState_value = map[string]int32{
+	"MOTIONGATE_UNCHANGED": 0,
+	"MOTIONGATE_ENABLE":    1,
+	"MOTIONGATE_DISABLE":   2,
+}
+
+func (x Action_MotionGateState) String() string {
+	return proto.EnumName(Action_MotionGateState_name, int32(x))
+}
+func (Action_MotionGateState) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{0, 4} }
+
+type Action_DownsamplingState int32
+
+const (
+	Action_DOWNSAMPLING_UNCHANGED Action_DownsamplingState = 0
+	Action_DOWNSAMPLING_ENABLE    Action_DownsamplingState = 1
+	Action_DOWNSAMPLING_DISABLE   Action_DownsamplingState = 2
+)
+
+var Action_DownsamplingState_name = map[int32]string{
+	0: "DOWNSAMPLING_UNCHANGED",
+	1: "DOWNSAMPLING_ENABLE",
+	2: "DOWNSAMPLING_DISABLE",
+}
+var Action_DownsamplingState_value = map[string]int32{
+	"DOWNSAMPLING_UNCHANGED": 0,
+	"DOWNSAMPLING_ENABLE":    1,
+	"DOWNSAMPLING_DISABLE":   2,
+}
+
+func (x Action_DownsamplingState) String() string {
+	return proto.EnumName(Action_DownsamplingState_name, int32(x))
+}
+func (Action_DownsamplingState) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{0, 5} }
+
+type Action_PrivacyState int32
+
+const (
+	Action_PRIVACY_UNCHANGED Action_PrivacyState = 0
+	Action_PRIVACY_ENABLE    Action_PrivacyState = 1
+	Action_PRIVACY_DISABLE   Action_PrivacyState = 2
+)
+
+var Action_PrivacyState_name = map[int32]string{
+	0: "PRIVACY_UNCHANGED",
+	1: "PRIVACY_ENABLE",
+	2: "PRIVACY_DISABLE",
+}
+var Action_PrivacyState_value = map[string]int32{
+	"PRIVACY_UNCHANGED": 0,
+	"PRIVACY_ENABLE":    1,
+	"PRIVACY_DISABLE":   2,
+}
+
+func (x Action_PrivacyState) String() string {
+	return proto.EnumName(Action_PrivacyState_name, int32(x))
+}
+func (Action_PrivacyState) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{0, 6} }
+
+type Action_PrivacyMethod int32
+
+const (
+	Action_PRIVACYMETHOD_UNCHANGED Action_PrivacyMethod = 0
+	Action_PRIVACYMETHOD_BLUR      Action_PrivacyMethod = 1
+	Action_PRIVACYMETHOD_PIXELATE  Action_PrivacyMethod = 2
+)
+
+var Action_PrivacyMethod_name = map[int32]string{
+	0: "PRIVACYMETHOD_UNCHANGED",
+	1: "PRIVACYMETHOD_BLUR",
+	2: "PRIVACYMETHOD_PIXELATE",
+}
+var Action_PrivacyMethod_value = map[string]int32{
+	"PRIVACYMETHOD_UNCHANGED": 0,
+	"PRIVACYMETHOD_BLUR":      1,
+	"PRIVACYMETHOD_PIXELATE":  2,
+}
+
+func (x Action_PrivacyMethod) String() string {
+	return proto.EnumName(Action_PrivacyMethod_name, int32(x))
+}
+func (Action_PrivacyMethod) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{0, 7} }
+
+type Action_DetectorBackend int32
+
+const (
+	Action_DETECTOR_UNCHANGED Action_DetectorBackend = 0
+	Action_DETECTOR_HAAR      Action_DetectorBackend = 1
+	Action_DETECTOR_PICO      Action_DetectorBackend = 2
+)
+
+var Action_DetectorBackend_name = map[int32]string{
+	0: "DETECTOR_UNCHANGED",
+	1: "DETECTOR_HAAR",
+	2: "DETECTOR_PICO",
+}
+var Action_DetectorBackend_value = map[string]int32{
+	"DETECTOR_UNCHANGED": 0,
+	"DETECTOR_HAAR":      1,
+	"DETECTOR_PICO":      2,
+}
+
+func (x Action_DetectorBackend) String() string {
+	return proto.EnumName(Action_DetectorBackend_name, int32(x))
+}
+func (Action_DetectorBackend) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{0, 8} }
+
 type Action struct {
-	FaceDetection Action_FaceDetectionState `protobuf:"varint,1,opt,name=faceDetection,enum=messages.Action_FaceDetectionState" json:"faceDetection,omitempty"`
-	RenderingMode Action_RenderingMode      `protobuf:"varint,2,opt,name=renderingMode,enum=messages.Action_RenderingMode" json:"renderingMode,omitempty"`
-	Camera        int32                     `protobuf:"varint,3,opt,name=Camera,json=camera" json:"Camera,omitempty"`
-	QuitServer    bool                      `protobuf:"varint,4,opt,name=QuitServer,json=quitServer" json:"QuitServer,omitempty"`
+	FaceDetection     Action_FaceDetectionState `protobuf:"varint,1,opt,name=faceDetection,enum=messages.Action_FaceDetectionState" json:"faceDetection,omitempty"`
+	RenderingMode     Action_RenderingMode      `protobuf:"varint,2,opt,name=renderingMode,enum=messages.Action_RenderingMode" json:"renderingMode,omitempty"`
+	Camera            int32                     `protobuf:"varint,3,opt,name=Camera,json=camera" json:"Camera,omitempty"`
+	QuitServer        bool                      `protobuf:"varint,4,opt,name=QuitServer,json=quitServer" json:"QuitServer,omitempty"`
+	Source            Action_FrameSource        `protobuf:"varint,5,opt,name=source,enum=messages.Action_FrameSource" json:"source,omitempty"`
+	SourcePath        string                    `protobuf:"bytes,6,opt,name=sourcePath" json:"sourcePath,omitempty"`
+	Sampling          Action_SamplingPolicy     `protobuf:"varint,7,opt,name=sampling,enum=messages.Action_SamplingPolicy" json:"sampling,omitempty"`
+	CaptureIntervalMs int32                     `protobuf:"varint,8,opt,name=captureIntervalMs" json:"captureIntervalMs,omitempty"`
+	FrameStep         int32                     `protobuf:"varint,9,opt,name=frameStep" json:"frameStep,omitempty"`
+	MaxFPS            float32                   `protobuf:"fixed32,10,opt,name=maxFPS" json:"maxFPS,omitempty"`
+	MotionThreshold   float32                   `protobuf:"fixed32,11,opt,name=motionThreshold" json:"motionThreshold,omitempty"`
+	MotionGate        Action_MotionGateState    `protobuf:"varint,26,opt,name=motionGate,enum=messages.Action_MotionGateState" json:"motionGate,omitempty"`
+	AggregateQuery    *AggregateQuery           `protobuf:"bytes,12,opt,name=aggregateQuery" json:"aggregateQuery,omitempty"`
+	RetentionDays     int32                     `protobuf:"varint,13,opt,name=retentionDays" json:"retentionDays,omitempty"`
+	Downsampling      Action_DownsamplingState  `protobuf:"varint,14,opt,name=downsampling,enum=messages.Action_DownsamplingState" json:"downsampling,omitempty"`
+	HistoryQuery      *HistoryQuery             `protobuf:"bytes,15,opt,name=historyQuery" json:"historyQuery,omitempty"`
+	Renderer          *Renderer                 `protobuf:"bytes,16,opt,name=renderer" json:"renderer,omitempty"`
+	Privacy           Action_PrivacyState       `protobuf:"varint,17,opt,name=privacy,enum=messages.Action_PrivacyState" json:"privacy,omitempty"`
+	PrivacyMethod     Action_PrivacyMethod      `protobuf:"varint,18,opt,name=privacyMethod,enum=messages.Action_PrivacyMethod" json:"privacyMethod,omitempty"`
+	Detector          Action_DetectorBackend    `protobuf:"varint,19,opt,name=detector,enum=messages.Action_DetectorBackend" json:"detector,omitempty"`
+	Cascades          *HaarCascades             `protobuf:"bytes,20,opt,name=cascades" json:"cascades,omitempty"`
+	VisitQuery        *VisitQuery               `protobuf:"bytes,21,opt,name=visitQuery" json:"visitQuery,omitempty"`
+	SetZone           *Zone                     `protobuf:"bytes,22,opt,name=setZone" json:"setZone,omitempty"`
+	DeleteZone        string                    `protobuf:"bytes,23,opt,name=deleteZone" json:"deleteZone,omitempty"`
+	ActiveCameras     *ActiveCameras            `protobuf:"bytes,24,opt,name=activeCameras" json:"activeCameras,omitempty"`
+	CameraParams      *CameraParams             `protobuf:"bytes,25,opt,name=cameraParams" json:"cameraParams,omitempty"`
 }
 
 func (m *Action) Reset()                    { *m = Action{} }
@@ -88,32 +303,500 @@ func (m *Action) String() string            { return proto.CompactTextString(m)
 func (*Action) ProtoMessage()               {}
 func (*Action) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
 
+func (m *Action) GetAggregateQuery() *AggregateQuery {
+	if m != nil {
+		return m.AggregateQuery
+	}
+	return nil
+}
+
+func (m *Action) GetHistoryQuery() *HistoryQuery {
+	if m != nil {
+		return m.HistoryQuery
+	}
+	return nil
+}
+
+func (m *Action) GetRenderer() *Renderer {
+	if m != nil {
+		return m.Renderer
+	}
+	return nil
+}
+
+func (m *Action) GetCascades() *HaarCascades {
+	if m != nil {
+		return m.Cascades
+	}
+	return nil
+}
+
+func (m *Action) GetVisitQuery() *VisitQuery {
+	if m != nil {
+		return m.VisitQuery
+	}
+	return nil
+}
+
+func (m *Action) GetSetZone() *Zone {
+	if m != nil {
+		return m.SetZone
+	}
+	return nil
+}
+
+func (m *Action) GetActiveCameras() *ActiveCameras {
+	if m != nil {
+		return m.ActiveCameras
+	}
+	return nil
+}
+
+func (m *Action) GetCameraParams() *CameraParams {
+	if m != nil {
+		return m.CameraParams
+	}
+	return nil
+}
+
+type CameraParams struct {
+	Camera       int32   `protobuf:"varint,1,opt,name=camera" json:"camera,omitempty"`
+	Width        int32   `protobuf:"varint,2,opt,name=width" json:"width,omitempty"`
+	Height       int32   `protobuf:"varint,3,opt,name=height" json:"height,omitempty"`
+	Fps          float64 `protobuf:"fixed64,4,opt,name=fps" json:"fps,omitempty"`
+	Exposure     float64 `protobuf:"fixed64,5,opt,name=exposure" json:"exposure,omitempty"`
+	AutoExposure bool    `protobuf:"varint,6,opt,name=autoExposure" json:"autoExposure,omitempty"`
+}
+
+func (m *CameraParams) Reset()                    { *m = CameraParams{} }
+func (m *CameraParams) String() string            { return proto.CompactTextString(m) }
+func (*CameraParams) ProtoMessage()               {}
+func (*CameraParams) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+
+type CameraInfo struct {
+	Camera int32         `protobuf:"varint,1,opt,name=camera" json:"camera,omitempty"`
+	Name   string        `protobuf:"bytes,2,opt,name=name" json:"name,omitempty"`
+	Modes  []*CameraMode `protobuf:"bytes,3,rep,name=modes" json:"modes,omitempty"`
+	Params *CameraParams `protobuf:"bytes,4,opt,name=params" json:"params,omitempty"`
+}
+
+func (m *CameraInfo) Reset()                    { *m = CameraInfo{} }
+func (m *CameraInfo) String() string            { return proto.CompactTextString(m) }
+func (*CameraInfo) ProtoMessage()               {}
+func (*CameraInfo) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+
+func (m *CameraInfo) GetModes() []*CameraMode {
+	if m != nil {
+		return m.Modes
+	}
+	return nil
+}
+
+func (m *CameraInfo) GetParams() *CameraParams {
+	if m != nil {
+		return m.Params
+	}
+	return nil
+}
+
+type CameraMode struct {
+	Width  int32     `protobuf:"varint,1,opt,name=width" json:"width,omitempty"`
+	Height int32     `protobuf:"varint,2,opt,name=height" json:"height,omitempty"`
+	Fps    []float64 `protobuf:"fixed64,3,rep,packed,name=fps" json:"fps,omitempty"`
+}
+
+func (m *CameraMode) Reset()                    { *m = CameraMode{} }
+func (m *CameraMode) String() string            { return proto.CompactTextString(m) }
+func (*CameraMode) ProtoMessage()               {}
+func (*CameraMode) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
+
+func (m *CameraMode) GetFps() []float64 {
+	if m != nil {
+		return m.Fps
+	}
+	return nil
+}
+
+type ActiveCameras struct {
+	Cameras []int32 `protobuf:"varint,1,rep,packed,name=cameras" json:"cameras,omitempty"`
+}
+
+func (m *ActiveCameras) Reset()                    { *m = ActiveCameras{} }
+func (m *ActiveCameras) String() string            { return proto.CompactTextString(m) }
+func (*ActiveCameras) ProtoMessage()               {}
+func (*ActiveCameras) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+
+func (m *ActiveCameras) GetCameras() []int32 {
+	if m != nil {
+		return m.Cameras
+	}
+	return nil
+}
+
+type Zone struct {
+	Name   string   `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	Points []*Point `protobuf:"bytes,2,rep,name=points" json:"points,omitempty"`
+	Camera int32    `protobuf:"varint,3,opt,name=camera" json:"camera,omitempty"`
+}
+
+func (m *Zone) Reset()                    { *m = Zone{} }
+func (m *Zone) String() string            { return proto.CompactTextString(m) }
+func (*Zone) ProtoMessage()               {}
+func (*Zone) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
+
+func (m *Zone) GetPoints() []*Point {
+	if m != nil {
+		return m.Points
+	}
+	return nil
+}
+
+type Point struct {
+	X float64 `protobuf:"fixed64,1,opt,name=x" json:"x,omitempty"`
+	Y float64 `protobuf:"fixed64,2,opt,name=y" json:"y,omitempty"`
+}
+
+func (m *Point) Reset()                    { *m = Point{} }
+func (m *Point) String() string            { return proto.CompactTextString(m) }
+func (*Point) ProtoMessage()               {}
+func (*Point) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
+
+type HaarCascades struct {
+	Models       []string `protobuf:"bytes,1,rep,name=models" json:"models,omitempty"`
+	ScaleFactor  float32  `protobuf:"fixed32,2,opt,name=scaleFactor" json:"scaleFactor,omitempty"`
+	MinNeighbors int32    `protobuf:"varint,3,opt,name=minNeighbors" json:"minNeighbors,omitempty"`
+	MinSize      int32    `protobuf:"varint,4,opt,name=minSize" json:"minSize,omitempty"`
+	MaxSize      int32    `protobuf:"varint,5,opt,name=maxSize" json:"maxSize,omitempty"`
+	NmsThreshold float32  `protobuf:"fixed32,6,opt,name=nmsThreshold" json:"nmsThreshold,omitempty"`
+}
+
+func (m *HaarCascades) Reset()                    { *m = HaarCascades{} }
+func (m *HaarCascades) String() string            { return proto.CompactTextString(m) }
+func (*HaarCascades) ProtoMessage()               {}
+func (*HaarCascades) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+
+func (m *HaarCascades) GetModels() []string {
+	if m != nil {
+		return m.Models
+	}
+	return nil
+}
+
+type Renderer struct {
+	Name   string            `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	Params map[string]string `protobuf:"bytes,2,rep,name=params" json:"params,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+}
+
+func (m *Renderer) Reset()                    { *m = Renderer{} }
+func (m *Renderer) String() string            { return proto.CompactTextString(m) }
+func (*Renderer) ProtoMessage()               {}
+func (*Renderer) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
+
+func (m *Renderer) GetParams() map[string]string {
+	if m != nil {
+		return m.Params
+	}
+	return nil
+}
+
+type AggregateQuery struct {
+	Bucket string `protobuf:"bytes,1,opt,name=bucket" json:"bucket,omitempty"`
+	From   int64  `protobuf:"varint,2,opt,name=from" json:"from,omitempty"`
+	To     int64  `protobuf:"varint,3,opt,name=to" json:"to,omitempty"`
+	Camera int32  `protobuf:"varint,4,opt,name=camera" json:"camera,omitempty"`
+}
+
+func (m *AggregateQuery) Reset()                    { *m = AggregateQuery{} }
+func (m *AggregateQuery) String() string            { return proto.CompactTextString(m) }
+func (*AggregateQuery) ProtoMessage()               {}
+func (*AggregateQuery) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
+
+type VisitQuery struct {
+	From int64 `protobuf:"varint,1,opt,name=from" json:"from,omitempty"`
+	To   int64 `protobuf:"varint,2,opt,name=to" json:"to,omitempty"`
+}
+
+func (m *VisitQuery) Reset()                    { *m = VisitQuery{} }
+func (m *VisitQuery) String() string            { return proto.CompactTextString(m) }
+func (*VisitQuery) ProtoMessage()               {}
+func (*VisitQuery) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
+
+type HistoryQuery struct {
+	Before int64 `protobuf:"varint,1,opt,name=before" json:"before,omitempty"`
+	After  int64 `protobuf:"varint,2,opt,name=after" json:"after,omitempty"`
+	Limit  int32 `protobuf:"varint,3,opt,name=limit" json:"limit,omitempty"`
+}
+
+func (m *HistoryQuery) Reset()                    { *m = HistoryQuery{} }
+func (m *HistoryQuery) String() string            { return proto.CompactTextString(m) }
+func (*HistoryQuery) ProtoMessage()               {}
+func (*HistoryQuery) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
+
+type Request struct {
+	Action    *Action       `protobuf:"bytes,1,opt,name=action" json:"action,omitempty"`
+	Subscribe *Subscription `protobuf:"bytes,2,opt,name=subscribe" json:"subscribe,omitempty"`
+}
+
+func (m *Request) Reset()                    { *m = Request{} }
+func (m *Request) String() string            { return proto.CompactTextString(m) }
+func (*Request) ProtoMessage()               {}
+func (*Request) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
+
+func (m *Request) GetAction() *Action {
+	if m != nil {
+		return m.Action
+	}
+	return nil
+}
+
+func (m *Request) GetSubscribe() *Subscription {
+	if m != nil {
+		return m.Subscribe
+	}
+	return nil
+}
+
+type Subscription struct {
+	Types []string `protobuf:"bytes,1,rep,name=types" json:"types,omitempty"`
+}
+
+func (m *Subscription) Reset()                    { *m = Subscription{} }
+func (m *Subscription) String() string            { return proto.CompactTextString(m) }
+func (*Subscription) ProtoMessage()               {}
+func (*Subscription) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
+
+func (m *Subscription) GetTypes() []string {
+	if m != nil {
+		return m.Types
+	}
+	return nil
+}
+
+type Event struct {
+	Type string `protobuf:"bytes,1,opt,name=type" json:"type,omitempty"`
+	Json []byte `protobuf:"bytes,2,opt,name=json" json:"json,omitempty"`
+}
+
+func (m *Event) Reset()                    { *m = Event{} }
+func (m *Event) String() string            { return proto.CompactTextString(m) }
+func (*Event) ProtoMessage()               {}
+func (*Event) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
+
+type Response struct {
+	Success bool    `protobuf:"varint,1,opt,name=success" json:"success,omitempty"`
+	Error   string  `protobuf:"bytes,2,opt,name=error" json:"error,omitempty"`
+	Status  *Status `protobuf:"bytes,3,opt,name=status" json:"status,omitempty"`
+}
+
+func (m *Response) Reset()                    { *m = Response{} }
+func (m *Response) String() string            { return proto.CompactTextString(m) }
+func (*Response) ProtoMessage()               {}
+func (*Response) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
+
+func (m *Response) GetStatus() *Status {
+	if m != nil {
+		return m.Status
+	}
+	return nil
+}
+
+type Status struct {
+	FaceDetection   bool          `protobuf:"varint,1,opt,name=faceDetection" json:"faceDetection,omitempty"`
+	Running         bool          `protobuf:"varint,2,opt,name=running" json:"running,omitempty"`
+	Camera          int32         `protobuf:"varint,3,opt,name=camera" json:"camera,omitempty"`
+	RenderingMode   string        `protobuf:"bytes,4,opt,name=renderingMode" json:"renderingMode,omitempty"`
+	Source          string        `protobuf:"bytes,5,opt,name=source" json:"source,omitempty"`
+	SourcePath      string        `protobuf:"bytes,6,opt,name=sourcePath" json:"sourcePath,omitempty"`
+	Clients         int32         `protobuf:"varint,7,opt,name=clients" json:"clients,omitempty"`
+	LastStatTime    int64         `protobuf:"varint,8,opt,name=lastStatTime" json:"lastStatTime,omitempty"`
+	LastStatPersons int32         `protobuf:"varint,9,opt,name=lastStatPersons" json:"lastStatPersons,omitempty"`
+	Privacy         bool          `protobuf:"varint,10,opt,name=privacy" json:"privacy,omitempty"`
+	Detector        string        `protobuf:"bytes,11,opt,name=detector" json:"detector,omitempty"`
+	Zones           []*Zone       `protobuf:"bytes,12,rep,name=zones" json:"zones,omitempty"`
+	ActiveCameras   []int32       `protobuf:"varint,13,rep,packed,name=activeCameras" json:"activeCameras,omitempty"`
+	RunningCameras  []int32       `protobuf:"varint,14,rep,packed,name=runningCameras" json:"runningCameras,omitempty"`
+	Cameras         []*CameraInfo `protobuf:"bytes,15,rep,name=cameras" json:"cameras,omitempty"`
+	CameraStatus    string        `protobuf:"bytes,16,opt,name=cameraStatus" json:"cameraStatus,omitempty"`
+	FailedCamera    int32         `protobuf:"varint,17,opt,name=failedCamera" json:"failedCamera,omitempty"`
+	CameraError     string        `protobuf:"bytes,18,opt,name=cameraError" json:"cameraError,omitempty"`
+	DetectionState  string        `protobuf:"bytes,19,opt,name=detectionState" json:"detectionState,omitempty"`
+	DetectionError  string        `protobuf:"bytes,20,opt,name=detectionError" json:"detectionError,omitempty"`
+}
+
+func (m *Status) Reset()                    { *m = Status{} }
+func (m *Status) String() string            { return proto.CompactTextString(m) }
+func (*Status) ProtoMessage()               {}
+func (*Status) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{16} }
+
+func (m *Status) GetZones() []*Zone {
+	if m != nil {
+		return m.Zones
+	}
+	return nil
+}
+
+func (m *Status) GetActiveCameras() []int32 {
+	if m != nil {
+		return m.ActiveCameras
+	}
+	return nil
+}
+
+func (m *Status) GetRunningCameras() []int32 {
+	if m != nil {
+		return m.RunningCameras
+	}
+	return nil
+}
+
+func (m *Status) GetCameras() []*CameraInfo {
+	if m != nil {
+		return m.Cameras
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*Action)(nil), "messages.Action")
+	proto.RegisterType((*CameraParams)(nil), "messages.CameraParams")
+	proto.RegisterType((*CameraInfo)(nil), "messages.CameraInfo")
+	proto.RegisterType((*CameraMode)(nil), "messages.CameraMode")
+	proto.RegisterType((*ActiveCameras)(nil), "messages.ActiveCameras")
+	proto.RegisterType((*Zone)(nil), "messages.Zone")
+	proto.RegisterType((*Point)(nil), "messages.Point")
+	proto.RegisterType((*HaarCascades)(nil), "messages.HaarCascades")
+	proto.RegisterType((*Renderer)(nil), "messages.Renderer")
+	proto.RegisterType((*AggregateQuery)(nil), "messages.AggregateQuery")
+	proto.RegisterType((*VisitQuery)(nil), "messages.VisitQuery")
+	proto.RegisterType((*HistoryQuery)(nil), "messages.HistoryQuery")
+	proto.RegisterType((*Request)(nil), "messages.Request")
+	proto.RegisterType((*Subscription)(nil), "messages.Subscription")
+	proto.RegisterType((*Event)(nil), "messages.Event")
+	proto.RegisterType((*Response)(nil), "messages.Response")
+	proto.RegisterType((*Status)(nil), "messages.Status")
 	proto.RegisterEnum("messages.Action_FaceDetectionState", Action_FaceDetectionState_name, Action_FaceDetectionState_value)
 	proto.RegisterEnum("messages.Action_RenderingMode", Action_RenderingMode_name, Action_RenderingMode_value)
+	proto.RegisterEnum("messages.Action_FrameSource", Action_FrameSource_name, Action_FrameSource_value)
+	proto.RegisterEnum("messages.Action_SamplingPolicy", Action_SamplingPolicy_name, Action_SamplingPolicy_value)
+	proto.RegisterEnum("messages.Action_MotionGateState", Action_MotionGateState_name, Action_MotionGateState_value)
+	proto.RegisterEnum("messages.Action_DownsamplingState", Action_DownsamplingState_name, Action_DownsamplingState_value)
+	proto.RegisterEnum("messages.Action_PrivacyState", Action_PrivacyState_name, Action_PrivacyState_value)
+	proto.RegisterEnum("messages.Action_PrivacyMethod", Action_PrivacyMethod_name, Action_PrivacyMethod_value)
+	proto.RegisterEnum("messages.Action_DetectorBackend", Action_DetectorBackend_name, Action_DetectorBackend_value)
 }
 
 func init() { proto.RegisterFile("communication.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 279 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x64, 0x91, 0x51, 0x4f, 0xb3, 0x30,
-	0x14, 0x86, 0x3f, 0xd8, 0x27, 0x59, 0x4e, 0x82, 0xc1, 0xea, 0x14, 0x63, 0xb2, 0x18, 0xbc, 0xf1,
-	0x8a, 0x0b, 0xfd, 0x05, 0x48, 0xcb, 0x24, 0x19, 0x25, 0x96, 0xed, 0xd2, 0x98, 0xca, 0xce, 0x16,
-	0x2e, 0x00, 0x2d, 0x9d, 0xbf, 0xc5, 0x9f, 0x2b, 0xc3, 0x68, 0x56, 0x77, 0xd9, 0xf3, 0x3c, 0xef,
-	0x79, 0x4f, 0x52, 0x38, 0x2d, 0xdb, 0xba, 0xde, 0x36, 0x55, 0x29, 0x75, 0xd5, 0x36, 0xe1, 0x9b,
-	0x6a, 0x75, 0x4b, 0xc6, 0x35, 0x76, 0x9d, 0xdc, 0x60, 0x17, 0x7c, 0x8e, 0xc0, 0x89, 0xca, 0x1d,
-	0x22, 0x29, 0xb8, 0x6b, 0x59, 0x22, 0x45, 0x8d, 0xc3, 0xc0, 0xb7, 0xae, 0xad, 0xdb, 0xe3, 0xbb,
-	0x9b, 0xf0, 0x47, 0x0e, 0xbf, 0xc5, 0x30, 0xd9, 0xb7, 0x0a, 0x2d, 0x35, 0x0a, 0x33, 0x49, 0x28,
-	0xb8, 0x0a, 0x9b, 0x15, 0xaa, 0xaa, 0xd9, 0x64, 0xed, 0x0a, 0x7d, 0x7b, 0x58, 0x35, 0x3d, 0x58,
-	0x25, 0xf6, 0x2d, 0x61, 0x86, 0xc8, 0x39, 0x38, 0xb1, 0xac, 0x51, 0x49, 0x7f, 0xd4, 0xc7, 0x8f,
-	0x84, 0x53, 0x0e, 0x2f, 0x32, 0x05, 0x78, 0xda, 0x56, 0xba, 0x40, 0xf5, 0x81, 0xca, 0xff, 0xdf,
-	0xb3, 0xb1, 0x80, 0xf7, 0xdf, 0x49, 0xb0, 0x06, 0x72, 0x78, 0x22, 0xb9, 0x82, 0x8b, 0x24, 0x8a,
-	0x19, 0x65, 0x0b, 0x16, 0x2f, 0xd2, 0x9c, 0xbf, 0x2c, 0x79, 0xfc, 0x18, 0xf1, 0x19, 0xa3, 0xde,
-	0x3f, 0xe2, 0xc3, 0x99, 0x09, 0x19, 0x8f, 0x1e, 0xe6, 0xcc, 0xb3, 0xc8, 0x25, 0x4c, 0x4c, 0x42,
-	0xd3, 0x62, 0x40, 0x76, 0xf0, 0x0c, 0xae, 0x71, 0xff, 0xae, 0x42, 0x30, 0x4e, 0x99, 0x48, 0xf9,
-	0x2c, 0xcb, 0x29, 0xfb, 0x5b, 0x61, 0x42, 0x9e, 0x8b, 0x2c, 0x9a, 0xf7, 0x15, 0x13, 0x38, 0x31,
-	0x49, 0xb2, 0xe4, 0x9e, 0xfd, 0xea, 0x0c, 0x7f, 0x75, 0xff, 0x15, 0x00, 0x00, 0xff, 0xff, 0xa0,
-	0x4b, 0x42, 0xce, 0xc2, 0x01, 0x00, 0x00,
+	// 1751 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x58, 0x6d, 0x6f, 0x23, 0x49,
+	0x11, 0x66, 0xec, 0xd8, 0xb1, 0x2b, 0x7e, 0x99, 0x74, 0xb2, 0xd9, 0xb9, 0xe5, 0x58, 0xac, 0xb9,
+	0x15, 0x18, 0x84, 0x0c, 0x5a, 0x56, 0xbc, 0x1c, 0x20, 0xdd, 0xac, 0x3d, 0xce, 0x5a, 0x8a, 0x5f,
+	0xae, 0xed, 0x5d, 0x8e, 0x43, 0x08, 0x3a, 0xe3, 0x76, 0x3c, 0xac, 0x67, 0xc6, 0x4c, 0xb7, 0x73,
+	0xf1, 0xfd, 0x0b, 0x24, 0xfe, 0x00, 0x5f, 0xf8, 0x11, 0x7c, 0xe6, 0x87, 0xa1, 0xee, 0x9e, 0xb7,
+	0xb6, 0x13, 0xc4, 0xb7, 0xae, 0xa7, 0x9e, 0xae, 0xae, 0xaa, 0xa9, 0x2e, 0x57, 0x1b, 0x2e, 0xbc,
+	0x28, 0x08, 0x76, 0xa1, 0xef, 0x11, 0xee, 0x47, 0x61, 0x6f, 0x1b, 0x47, 0x3c, 0x42, 0xb5, 0x80,
+	0x32, 0x46, 0xee, 0x28, 0xb3, 0xff, 0x79, 0x0e, 0x55, 0xc7, 0x13, 0x2a, 0x34, 0x82, 0xe6, 0x8a,
+	0x78, 0x74, 0x40, 0x39, 0x95, 0x80, 0x65, 0x74, 0x8c, 0x6e, 0xeb, 0xf5, 0x67, 0xbd, 0x94, 0xdc,
+	0x53, 0xc4, 0xde, 0xb0, 0xc8, 0x9a, 0x73, 0xc2, 0x29, 0xd6, 0x77, 0xa2, 0x01, 0x34, 0x63, 0x1a,
+	0x2e, 0x69, 0xec, 0x87, 0x77, 0xe3, 0x68, 0x49, 0xad, 0x92, 0x34, 0xf5, 0xf2, 0xc8, 0x14, 0x2e,
+	0xb2, 0xb0, 0xbe, 0x09, 0x5d, 0x41, 0xb5, 0x4f, 0x02, 0x1a, 0x13, 0xab, 0xdc, 0x31, 0xba, 0x15,
+	0x9c, 0x48, 0xe8, 0x25, 0xc0, 0x97, 0x3b, 0x9f, 0xcf, 0x69, 0x7c, 0x4f, 0x63, 0xeb, 0xa4, 0x63,
+	0x74, 0x6b, 0xb8, 0x80, 0xa0, 0x37, 0x50, 0x65, 0xd1, 0x2e, 0xf6, 0xa8, 0x55, 0x91, 0xc7, 0x7e,
+	0x7a, 0x1c, 0x41, 0x4c, 0x02, 0x3a, 0x97, 0x1c, 0x9c, 0x70, 0x85, 0x55, 0xb5, 0x9a, 0x11, 0xbe,
+	0xb6, 0xaa, 0x1d, 0xa3, 0x5b, 0xc7, 0x05, 0x04, 0xfd, 0x06, 0x6a, 0x8c, 0x04, 0xdb, 0x8d, 0x1f,
+	0xde, 0x59, 0xa7, 0xd2, 0xee, 0xf7, 0x8f, 0xec, 0xce, 0x13, 0xc2, 0x2c, 0xda, 0xf8, 0xde, 0x1e,
+	0x67, 0x1b, 0xd0, 0x4f, 0xe0, 0xdc, 0x23, 0x5b, 0xbe, 0x8b, 0xe9, 0x28, 0xe4, 0x34, 0xbe, 0x27,
+	0x9b, 0x31, 0xb3, 0x6a, 0x32, 0xaa, 0x63, 0x05, 0xfa, 0x14, 0xea, 0x2b, 0xe9, 0x21, 0xa7, 0x5b,
+	0xab, 0x2e, 0x59, 0x39, 0x20, 0xd2, 0x12, 0x90, 0x87, 0xe1, 0x6c, 0x6e, 0x41, 0xc7, 0xe8, 0x96,
+	0x70, 0x22, 0xa1, 0x2e, 0xb4, 0x83, 0x48, 0xb8, 0xb1, 0x58, 0xc7, 0x94, 0xad, 0xa3, 0xcd, 0xd2,
+	0x3a, 0x93, 0x84, 0x43, 0x18, 0x7d, 0x01, 0xa0, 0xa0, 0x6b, 0xc2, 0xa9, 0xf5, 0x42, 0x06, 0xd3,
+	0x39, 0x0a, 0x66, 0x9c, 0x51, 0xd4, 0x37, 0x2e, 0xec, 0x41, 0x5f, 0x40, 0x8b, 0xdc, 0xdd, 0xc5,
+	0xf4, 0x8e, 0x70, 0xfa, 0xe5, 0x8e, 0xc6, 0x7b, 0xab, 0xd1, 0x31, 0xba, 0x67, 0xaf, 0xad, 0x82,
+	0x15, 0x4d, 0x8f, 0x0f, 0xf8, 0xe8, 0x95, 0x28, 0x11, 0x4e, 0x43, 0x61, 0x72, 0x40, 0xf6, 0xcc,
+	0x6a, 0xca, 0x38, 0x75, 0x10, 0x0d, 0xa1, 0xb1, 0x8c, 0xbe, 0x09, 0xb3, 0xc4, 0xb7, 0xa4, 0xaf,
+	0xf6, 0x91, 0xaf, 0x83, 0x02, 0x49, 0x79, 0xab, 0xed, 0x43, 0x9f, 0x43, 0x63, 0xed, 0x33, 0x1e,
+	0xc5, 0x7b, 0xe5, 0x6d, 0x5b, 0x7a, 0x7b, 0x95, 0xdb, 0x79, 0x57, 0xd0, 0x62, 0x8d, 0x8b, 0x7a,
+	0x50, 0x53, 0x75, 0x49, 0x63, 0xcb, 0x94, 0xfb, 0x50, 0xbe, 0x0f, 0x27, 0x1a, 0x9c, 0x71, 0xd0,
+	0x2f, 0xe1, 0x74, 0x1b, 0xfb, 0xf7, 0xc4, 0xdb, 0x5b, 0xe7, 0xd2, 0xdd, 0xef, 0x1d, 0xb9, 0x3b,
+	0x53, 0x7a, 0xe5, 0x69, 0xca, 0x16, 0xb7, 0x26, 0x59, 0x8e, 0x29, 0x5f, 0x47, 0x4b, 0x0b, 0x3d,
+	0x71, 0x6b, 0x66, 0x45, 0x16, 0xd6, 0x37, 0xa1, 0xdf, 0x42, 0x6d, 0x29, 0x2f, 0x62, 0x14, 0x5b,
+	0x17, 0x4f, 0x7c, 0xda, 0x41, 0x42, 0x78, 0x4b, 0xbc, 0x8f, 0x34, 0x5c, 0xe2, 0x6c, 0x07, 0x7a,
+	0x0d, 0x35, 0x8f, 0x30, 0x8f, 0x2c, 0x29, 0xb3, 0x2e, 0x8f, 0x92, 0x44, 0x48, 0xdc, 0x4f, 0xb4,
+	0x38, 0xe3, 0xa1, 0x37, 0x00, 0xf7, 0x3e, 0xf3, 0xb9, 0x4a, 0xed, 0x33, 0xb9, 0xeb, 0x32, 0xdf,
+	0xf5, 0x21, 0xd3, 0xe1, 0x02, 0x0f, 0x75, 0xe1, 0x94, 0x51, 0xfe, 0x75, 0x14, 0x52, 0xeb, 0x4a,
+	0x6e, 0x69, 0xe5, 0x5b, 0x04, 0x8a, 0x53, 0xb5, 0xb8, 0x99, 0x4b, 0xba, 0xa1, 0x9c, 0x4a, 0xf2,
+	0x73, 0x75, 0x33, 0x73, 0x04, 0xfd, 0x0e, 0x9a, 0xc4, 0xe3, 0xfe, 0x3d, 0x55, 0xfd, 0x81, 0x59,
+	0x96, 0xb4, 0xf7, 0x5c, 0x0f, 0x3b, 0x53, 0x63, 0x9d, 0x2d, 0x6a, 0xc3, 0x93, 0xcb, 0x19, 0x89,
+	0x49, 0xc0, 0xac, 0x4f, 0x0e, 0xc3, 0xee, 0x17, 0xb4, 0x58, 0xe3, 0xda, 0x2b, 0x40, 0xc7, 0xdd,
+	0x10, 0x7d, 0x17, 0x9e, 0x0f, 0x9d, 0xbe, 0x3b, 0x70, 0x17, 0x6e, 0x7f, 0x31, 0x9a, 0x4e, 0xfe,
+	0xfc, 0x7e, 0xd2, 0x7f, 0xe7, 0x4c, 0xae, 0xdd, 0x81, 0xf9, 0x1d, 0x64, 0xc1, 0xa5, 0xae, 0x74,
+	0x27, 0xce, 0xdb, 0x1b, 0xd7, 0x34, 0xd0, 0x27, 0xf0, 0x4c, 0xd7, 0x0c, 0x46, 0x73, 0xa9, 0x2a,
+	0xd9, 0x7f, 0x82, 0xa6, 0xd6, 0x2a, 0xc5, 0x11, 0xd8, 0x9d, 0x0c, 0x5c, 0x3c, 0x9a, 0x5c, 0x8f,
+	0xa7, 0x03, 0xf7, 0xf0, 0x08, 0x5d, 0x39, 0x99, 0xe2, 0xb1, 0x73, 0x63, 0x1a, 0xe8, 0x19, 0x9c,
+	0xeb, 0x9a, 0xe1, 0xfb, 0x89, 0x59, 0xb2, 0x43, 0x38, 0x2b, 0xb4, 0x44, 0x74, 0x09, 0xe6, 0x7c,
+	0xfa, 0x1e, 0xf7, 0x75, 0xab, 0xe7, 0xd0, 0x4c, 0xd0, 0xbe, 0x33, 0x76, 0xb1, 0x63, 0x1a, 0xc8,
+	0x84, 0x46, 0x02, 0x7d, 0x18, 0x0d, 0xdc, 0xa9, 0x59, 0x2a, 0x90, 0x46, 0x63, 0xe7, 0xda, 0x9d,
+	0x9b, 0xe5, 0x02, 0x34, 0x5f, 0x60, 0xd7, 0x19, 0x9b, 0x27, 0xb6, 0x0f, 0x2d, 0xbd, 0x55, 0xa2,
+	0x2b, 0x40, 0x73, 0x67, 0x3c, 0xbb, 0x19, 0x4d, 0xae, 0xb5, 0x43, 0x9f, 0xc1, 0x79, 0x86, 0x8f,
+	0x26, 0x0b, 0x17, 0x7f, 0x90, 0x71, 0x5c, 0x40, 0x3b, 0x83, 0x87, 0xd8, 0x19, 0xbb, 0x73, 0xb3,
+	0xa4, 0x81, 0x63, 0xe7, 0xab, 0xe1, 0x6c, 0x6e, 0x9e, 0xd8, 0x5f, 0x43, 0xfb, 0xa0, 0x91, 0x89,
+	0xf4, 0x8c, 0xa7, 0x22, 0xc1, 0xd7, 0xce, 0xc2, 0x3d, 0x3c, 0xad, 0xa0, 0xc9, 0x3e, 0xcc, 0x15,
+	0xa0, 0x02, 0x9c, 0x7f, 0x95, 0x5b, 0x38, 0x3f, 0x6a, 0x3c, 0xe8, 0x05, 0x5c, 0x0d, 0xa6, 0xbf,
+	0x9f, 0x3c, 0x1a, 0xcd, 0x73, 0xb8, 0xd0, 0x74, 0xd9, 0x09, 0x16, 0x5c, 0x6a, 0x8a, 0xfc, 0x8c,
+	0x09, 0x34, 0x8a, 0xdd, 0x42, 0xb8, 0x38, 0xc3, 0xa3, 0x0f, 0x4e, 0xff, 0x0f, 0x9a, 0x65, 0x04,
+	0xad, 0x14, 0xce, 0x8c, 0x5e, 0x40, 0x3b, 0xc5, 0x72, 0x7b, 0x7f, 0x81, 0xa6, 0xd6, 0x3e, 0x44,
+	0x25, 0x25, 0xac, 0xb1, 0xbb, 0x78, 0x37, 0x1d, 0x68, 0x66, 0xaf, 0x00, 0xe9, 0xca, 0xb7, 0x37,
+	0xef, 0xb1, 0x69, 0x88, 0x20, 0x75, 0x7c, 0x36, 0xfa, 0xca, 0xbd, 0x71, 0x16, 0xe2, 0x84, 0x29,
+	0xb4, 0x0f, 0xfa, 0x8b, 0x30, 0xa3, 0xaa, 0x7a, 0x8a, 0x0f, 0x4b, 0x2a, 0xc3, 0xdf, 0x39, 0x8e,
+	0xb0, 0x5c, 0x84, 0x66, 0xa3, 0xfe, 0xd4, 0x2c, 0xd9, 0xff, 0x32, 0xa0, 0x51, 0xbc, 0x83, 0xe2,
+	0x17, 0x50, 0xdd, 0x42, 0x39, 0xa2, 0x54, 0x70, 0x22, 0xa1, 0x4b, 0xa8, 0x7c, 0xe3, 0x2f, 0xf9,
+	0x5a, 0x8e, 0x1b, 0x15, 0xac, 0x04, 0xc1, 0x5e, 0x53, 0xff, 0x6e, 0xcd, 0xd3, 0x31, 0x42, 0x49,
+	0xc8, 0x84, 0xf2, 0x6a, 0xcb, 0xe4, 0xfc, 0x60, 0x60, 0xb1, 0x44, 0x2f, 0xa0, 0x46, 0x1f, 0xb6,
+	0x11, 0xdb, 0xc5, 0x6a, 0x74, 0x30, 0x70, 0x26, 0x23, 0x1b, 0x1a, 0x64, 0xc7, 0x23, 0x37, 0xd5,
+	0x57, 0xe5, 0xd8, 0xa1, 0x61, 0xf6, 0x3f, 0x0c, 0x00, 0xe5, 0xe8, 0x28, 0x5c, 0x45, 0x4f, 0xba,
+	0x89, 0xe0, 0x24, 0x24, 0x81, 0x1a, 0x8a, 0xea, 0x58, 0xae, 0xd1, 0x8f, 0xa1, 0x12, 0x44, 0xa2,
+	0xe9, 0x96, 0x3b, 0x65, 0xbd, 0x7d, 0x2a, 0x83, 0x72, 0x3e, 0x52, 0x14, 0xd4, 0x83, 0xea, 0x56,
+	0xb5, 0xaa, 0x93, 0xff, 0xd9, 0xaa, 0x12, 0x96, 0x7d, 0x03, 0x90, 0x1b, 0xc9, 0x93, 0x64, 0x3c,
+	0x9e, 0xa4, 0xd2, 0x63, 0x49, 0x12, 0x5e, 0xa9, 0x24, 0xd9, 0x3f, 0x82, 0xa6, 0xd6, 0x4e, 0x91,
+	0x05, 0xa7, 0x2a, 0x30, 0x66, 0x19, 0x9d, 0x72, 0xb7, 0x82, 0x53, 0xd1, 0xfe, 0x23, 0x9c, 0xc8,
+	0x06, 0x9d, 0x06, 0x6c, 0x14, 0x02, 0xfe, 0x21, 0x54, 0xb7, 0x91, 0x1f, 0x72, 0x66, 0x95, 0x64,
+	0xc4, 0xed, 0x3c, 0x88, 0x99, 0xc0, 0x71, 0xa2, 0x2e, 0x64, 0xb1, 0x5c, 0xcc, 0xa2, 0xfd, 0x19,
+	0x54, 0x24, 0x11, 0x35, 0xc0, 0x78, 0x90, 0xa6, 0x0d, 0x6c, 0x3c, 0x08, 0x69, 0x2f, 0x63, 0x30,
+	0xb0, 0xb1, 0xb7, 0xff, 0x63, 0x40, 0xa3, 0xf8, 0xab, 0x25, 0xac, 0x89, 0x24, 0x6e, 0x94, 0xaf,
+	0x75, 0x9c, 0x48, 0xa8, 0x03, 0x67, 0xcc, 0x23, 0x1b, 0x3a, 0x24, 0xf2, 0x87, 0xb3, 0x24, 0x07,
+	0xa7, 0x22, 0x24, 0x0a, 0x20, 0xf0, 0xc3, 0x89, 0x48, 0xcb, 0x6d, 0x14, 0xb3, 0xc4, 0x1b, 0x0d,
+	0x13, 0xa9, 0x08, 0xfc, 0x70, 0xee, 0x7f, 0x4b, 0xe5, 0xa7, 0xa9, 0xe0, 0x54, 0x94, 0x1a, 0xf2,
+	0x20, 0x35, 0x95, 0x44, 0xa3, 0x44, 0x61, 0x37, 0x0c, 0x58, 0x3e, 0xb3, 0x55, 0xe5, 0xd1, 0x1a,
+	0x66, 0xff, 0xdd, 0x80, 0x5a, 0x3a, 0x69, 0x3c, 0x9a, 0xcd, 0x5f, 0x64, 0x25, 0xa1, 0xb2, 0xf9,
+	0xf2, 0x78, 0x42, 0xe9, 0xa9, 0xaa, 0x70, 0x43, 0x1e, 0xef, 0xd3, 0xd2, 0x78, 0xf1, 0x6b, 0x38,
+	0x2b, 0xc0, 0xe2, 0x6b, 0x7f, 0xa4, 0xfb, 0xc4, 0xb2, 0x58, 0x8a, 0x6a, 0xb9, 0x27, 0x9b, 0x5d,
+	0x5a, 0xac, 0x4a, 0xf8, 0xbc, 0xf4, 0x2b, 0xc3, 0x5e, 0x42, 0x4b, 0x1f, 0xf1, 0x44, 0x6e, 0x6f,
+	0x77, 0xde, 0x47, 0xca, 0x13, 0x03, 0x89, 0x24, 0x1c, 0x5e, 0xc5, 0x51, 0x20, 0x4d, 0x94, 0xb1,
+	0x5c, 0xa3, 0x16, 0x94, 0x78, 0x24, 0x73, 0x58, 0xc6, 0x25, 0x5e, 0xbc, 0x2b, 0x27, 0xda, 0x57,
+	0xfe, 0x19, 0x40, 0x3e, 0x3f, 0x64, 0x96, 0x8c, 0x23, 0x4b, 0xa5, 0xd4, 0x92, 0x8d, 0xa1, 0x51,
+	0x1c, 0xe6, 0xa4, 0x57, 0x74, 0x15, 0xc5, 0x34, 0xd9, 0x95, 0x48, 0x22, 0x32, 0xb2, 0xe2, 0x34,
+	0x4e, 0xb6, 0x2a, 0x41, 0xa0, 0x1b, 0x3f, 0xf0, 0xd3, 0x5e, 0xa1, 0x04, 0xdb, 0x87, 0x53, 0x4c,
+	0xff, 0xb6, 0xa3, 0x8c, 0xa3, 0x2e, 0x54, 0x49, 0xfe, 0x3c, 0x3a, 0x7b, 0x6d, 0x1e, 0x0e, 0x57,
+	0x38, 0xd1, 0xa3, 0x37, 0x50, 0x67, 0xbb, 0x5b, 0xe6, 0xc5, 0xfe, 0xad, 0x4a, 0x9f, 0x76, 0x53,
+	0xe7, 0x4a, 0xb5, 0x95, 0x5b, 0x72, 0xa2, 0xfd, 0x0a, 0x1a, 0x45, 0x95, 0x70, 0x88, 0xef, 0xb7,
+	0x34, 0xad, 0x57, 0x25, 0xd8, 0x3f, 0x85, 0x8a, 0x7b, 0x4f, 0x43, 0x99, 0x5b, 0x81, 0xa4, 0xc5,
+	0x20, 0xd6, 0x02, 0xfb, 0x2b, 0x8b, 0x42, 0x79, 0x66, 0x03, 0xcb, 0xb5, 0xbd, 0x14, 0x05, 0xc4,
+	0xb6, 0x51, 0xc8, 0x64, 0x2d, 0xb2, 0x9d, 0xe7, 0x51, 0xc6, 0xe4, 0xb6, 0x1a, 0x4e, 0x45, 0x71,
+	0x18, 0x8d, 0xe3, 0xa4, 0xfe, 0xeb, 0x58, 0x09, 0x22, 0x64, 0xc6, 0x09, 0xdf, 0xa9, 0x9a, 0xd7,
+	0x42, 0x9e, 0x4b, 0x1c, 0x27, 0x7a, 0xfb, 0xdf, 0x15, 0xa8, 0x2a, 0x48, 0xcc, 0xf7, 0xc7, 0xaf,
+	0xc9, 0xda, 0xe1, 0x43, 0xd1, 0x82, 0xd3, 0x78, 0x17, 0x86, 0x62, 0xb4, 0x2f, 0x29, 0x57, 0x12,
+	0xf1, 0xa9, 0x6b, 0xaf, 0xde, 0x0d, 0xc5, 0xa7, 0xe5, 0x89, 0x74, 0xf5, 0xf8, 0xe9, 0x58, 0x78,
+	0x02, 0xd6, 0xff, 0xef, 0x47, 0x9e, 0xe8, 0x65, 0x1b, 0x9f, 0x8a, 0xb6, 0x74, 0xaa, 0xae, 0x69,
+	0x22, 0x8a, 0x6b, 0xba, 0x21, 0x8c, 0x8b, 0xe8, 0x16, 0x7e, 0x40, 0xe5, 0xe3, 0xad, 0x8c, 0x35,
+	0x4c, 0xbc, 0xc0, 0x52, 0x79, 0x46, 0x63, 0x16, 0x85, 0x2c, 0x79, 0xbd, 0x1d, 0xc2, 0xe2, 0x9c,
+	0xf4, 0x8d, 0x00, 0x2a, 0xee, 0x44, 0x14, 0xbf, 0x41, 0xd9, 0xf8, 0x7e, 0x26, 0xfd, 0xcb, 0x64,
+	0xf4, 0x0a, 0x2a, 0xdf, 0x46, 0x21, 0x65, 0x56, 0xa3, 0x53, 0x7e, 0x64, 0x60, 0x56, 0x4a, 0x91,
+	0x21, 0x7d, 0x1c, 0x6e, 0xca, 0xae, 0xac, 0x83, 0xe8, 0x07, 0xd0, 0x4a, 0x52, 0x9d, 0xd2, 0x5a,
+	0x92, 0x76, 0x80, 0xa2, 0x5e, 0xde, 0xdd, 0xdb, 0x8f, 0xff, 0x34, 0x89, 0xdf, 0xba, 0xac, 0xe7,
+	0x8b, 0x3c, 0xa9, 0xa5, 0xaa, 0x03, 0xf9, 0x62, 0xaa, 0x63, 0x0d, 0x13, 0x9c, 0x15, 0xf1, 0x37,
+	0x74, 0xa9, 0x0c, 0xc8, 0x67, 0x52, 0x05, 0x6b, 0x98, 0x68, 0xc8, 0x6a, 0x8f, 0x2b, 0x0b, 0x12,
+	0x49, 0x33, 0x45, 0x48, 0x44, 0xb0, 0xd4, 0xe6, 0x6e, 0xf9, 0xdc, 0xa9, 0xe3, 0x03, 0x54, 0xe3,
+	0x29, 0x63, 0x97, 0x07, 0x3c, 0x89, 0xde, 0x56, 0xe5, 0x7f, 0x23, 0x3f, 0xff, 0xef, 0x00, 0x89,
+	0xfa, 0xd7, 0x4d, 0x32, 0x11, 0x00, 0x00,
 }
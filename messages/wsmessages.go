@@ -1,17 +1,42 @@
 package messages
 
-import "github.com/ubuntu/face-detection-demo/datastore"
+import (
+	"github.com/ubuntu/face-detection-demo/appstate"
+	"github.com/ubuntu/face-detection-demo/datastore"
+)
 
 // WSMessage to be sent to clients
 type WSMessage struct {
-	Type                    string               `json:"type"`
-	AllStats                []datastore.Stat     `json:"allstats"`
-	NewStat                 *datastore.Stat      `json:"newstat"`
-	RefreshScreenshot       bool                 `json:"refreshscreenshot"`
-	RefreshDetectScreenshot bool                 `json:"refreshdetectscreenshot"`
-	FaceDetection           bool                 `json:"facedetection"`
-	RenderingMode           datastore.RenderMode `json:"renderingmode"`
-	Camera                  int                  `json:"camera"`
-	AvailableCameras        []int                `json:"availablecameras"`
-	Broken                  bool                 `json:"broken"`
+	Type                    string                         `json:"type"`
+	AllStats                []datastore.Stat               `json:"allstats"`
+	History                 []datastore.Stat               `json:"history"`
+	HistoryCursor           int64                          `json:"historycursor"`
+	HasMoreHistory          bool                           `json:"hasmorehistory"`
+	NewStat                 *datastore.Stat                `json:"newstat"`
+	Detections              []datastore.Detection          `json:"detections"`
+	Aggregates              []datastore.AggregatedStat     `json:"aggregates"`
+	RefreshScreenshot       bool                           `json:"refreshscreenshot"`
+	RefreshDetectScreenshot bool                           `json:"refreshdetectscreenshot"`
+	FaceDetection           bool                           `json:"facedetection"`
+	DetectionState          string                         `json:"detectionstate"`
+	DetectionError          string                         `json:"detectionerror"`
+	Renderer                *datastore.Renderer            `json:"renderer"`
+	Camera                  int                            `json:"camera"`
+	AvailableCameras        []int                          `json:"availablecameras"`
+	ActiveCameras           []int                          `json:"activecameras"`
+	CameraInfos             []appstate.CameraInfo          `json:"camerainfos"`
+	CameraParams            map[int]datastore.CameraParams `json:"cameraparams"`
+	CameraState             *appstate.CameraState          `json:"camerastate"`
+	AvailableRenderers      []string                       `json:"availablerenderers"`
+	Source                  datastore.FrameSourceKind      `json:"source"`
+	SourcePath              string                         `json:"sourcepath"`
+	Sampling                *datastore.Sampling            `json:"sampling"`
+	Retention               *datastore.Retention           `json:"retention"`
+	Privacy                 *datastore.Privacy             `json:"privacy"`
+	Detector                datastore.DetectorKind         `json:"detector"`
+	Cascades                *datastore.Cascades            `json:"cascades"`
+	Visit                   *datastore.Visit               `json:"visit"`
+	VisitStats              *datastore.VisitStats          `json:"visitstats"`
+	Zones                   []datastore.Zone               `json:"zones"`
+	Broken                  bool                           `json:"broken"`
 }
@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// Persons is the number of persons detected on last processed frame of each camera, offsetted by 1 like for
+	// clients (0 for other frame sources)
+	Persons = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "facedetection_persons",
+		Help: "Number of persons detected on last processed frame, by camera.",
+	}, []string{"camera"})
+	// Visitors counts faces tracked across frames, each one counted once
+	Visitors = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "facedetection_visitors_total",
+		Help: "Number of distinct faces followed across frames.",
+	})
+	// VisitDuration measures how long tracked persons stayed in sight
+	VisitDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "facedetection_visit_duration_seconds",
+		Help:    "Time tracked persons stayed in sight.",
+		Buckets: []float64{10, 30, 60, 300, 900},
+	})
+	// FramesGrabbed counts frames read from the frame source
+	FramesGrabbed = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "facedetection_frames_grabbed_total",
+		Help: "Number of frames grabbed from the frame source.",
+	})
+	// FramesProcessed counts frames face detection ran on
+	FramesProcessed = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "facedetection_frames_processed_total",
+		Help: "Number of frames face detection ran on.",
+	})
+	// DetectionDuration measures face detection time on a frame
+	DetectionDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "facedetection_detection_duration_seconds",
+		Help:    "Time spent detecting faces on a frame.",
+		Buckets: prometheus.ExponentialBuckets(0.01, 2, 10),
+	})
+	// WSClients is the number of connected websocket clients
+	WSClients = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "facedetection_websocket_clients",
+		Help: "Number of connected websocket clients.",
+	})
+	// WSDroppedSends counts messages which couldn't be sent to a websocket client
+	WSDroppedSends = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "facedetection_websocket_dropped_sends_total",
+		Help: "Number of messages dropped because a websocket client send buffer was full.",
+	})
+	// DBInsertFailures counts stats and visits which couldn't be saved to the database
+	DBInsertFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "facedetection_db_insert_failures_total",
+		Help: "Number of stats and visits which couldn't be saved to the database.",
+	})
+	// CameraOpenFailures counts failed attempts to open a camera
+	CameraOpenFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "facedetection_camera_open_failures_total",
+		Help: "Number of failed attempts to open a camera.",
+	})
+	// CameraDisconnects counts cameras lost while detection was running on them
+	CameraDisconnects = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "facedetection_camera_disconnects_total",
+		Help: "Number of cameras which stopped providing frames while detection was running.",
+	})
+	// MotionLevel is the difference between the last frame of each camera and the last processed one, between 0
+	// and 1. Only measured when detection is gated on motion
+	MotionLevel = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "facedetection_motion_level",
+		Help: "Mean pixel difference (0-1) between the last sampled frame and the last processed one, by camera.",
+	}, []string{"camera"})
+	// FramesWithoutMotion counts sampled frames face detection didn't run on because the scene didn't change
+	FramesWithoutMotion = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "facedetection_frames_without_motion_total",
+		Help: "Number of sampled frames skipped because the scene didn't change enough.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(Persons, Visitors, VisitDuration, FramesGrabbed, FramesProcessed, DetectionDuration,
+		WSClients, WSDroppedSends, DBInsertFailures, CameraOpenFailures, CameraDisconnects,
+		MotionLevel, FramesWithoutMotion)
+}